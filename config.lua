@@ -10,6 +10,51 @@ config {
   pagination1         = 3,
   pagination2         = 50,
   trim_html           = true,
+  summary_words       = 50,
+  toc                 = { min_level = 2, max_level = 4 },
+  words_per_minute    = 200,
+  cjk_chars_per_minute = 500,
+  related             = {
+    count       = 5,
+    tag_weight  = 1.0,
+    -- TF-IDF similarity of article texts. 0 disables it. It is slow on
+    -- sites with thousands of articles whenever an article changes.
+    text_weight = 0,
+  },
+
+  feeds = {
+    atom         = "atom.xml",
+    json         = "feed.json",
+    size         = 10,
+    full_content = true,
+    -- title, description and author.name default to params
+    author       = {
+      name   = "",
+      email  = "",
+      url    = "",
+      avatar = "",
+    },
+  },
+
+  sitemap = {
+    name        = "sitemap.xml",
+    -- glob patterns of output paths excluded from the sitemap
+    exclude     = {"include/*", "404.html"},
+    robots      = "robots.txt",
+    robots_text = [[
+User-agent: *
+Disallow:
+]],
+  },
+
+  search = {
+    name          = "search.json",
+    -- title, url, tags, date, summary, body and tokens
+    fields        = {"title", "url", "tags", "date", "summary", "tokens"},
+    body_size     = 2000,
+    size          = 0,
+    shard_by_year = false,
+  },
 
   params = {
     author              = "Your name",
@@ -19,28 +64,61 @@ config {
     disqus_short_name   = "",
   },
 
+  -- authors referred by the author header of articles
+  authors = {
+    {
+      name   = "Your name",
+      bio    = "",
+      avatar = "",
+      url    = "",
+      email  = "",
+    },
+  },
+
   top_url_path        = "",
   article_url_path    = [[articles/{{ .PostedAt.Year | printf "%04d" }}/{{ .PostedAt.Month | printf "%02d" }}/{{ .PostedAt.Day | printf "%02d" }}/{{ .Slug }}.html]],
   article_title       = [[{{ .App.Config.Params.SiteName }} :: {{ .Article.Title }}]],
 
+  page_url_path       = [[{{ .Slug }}.html]],
+  page_title          = [[{{ .App.Config.Params.SiteName }} :: {{ .Article.Title }}]],
+
   index_url_path      = [[{{if (eq .Page 0)}}index.html{{else}}page/{{ .Page }}/index.html{{end}}]],
   index_title         = [[{{.App.Config.Params.SiteName}}]],
 
   tag_url_path        = [[articles/tag/{{ .Tag }}/{{if (ne .Page 0)}}page/{{ .Page }}/{{end}}index.html]],
   tag_title           = [[{{.App.Config.Params.SiteName}} :: tag :: {{.Tag}}]],
+  tag_feed_url_path   = [[articles/tag/{{ .Tag }}/{{ .Name }}]],
 
   annual_url_path     = [[articles/{{ .Year | printf "%04d" }}/{{if (ne .Page 0)}}page/{{ .Page }}/{{end}}index.html]],
   annual_title        = [[{{.App.Config.Params.SiteName}} :: annual archive :: {{.Year}}]],
+  -- annual_feed_url_path = [[articles/{{ .Year | printf "%04d" }}/{{ .Name }}]],
 
   monthly_url_path    = [[articles/{{ .Year | printf "%04d" }}/{{ .Month | printf "%02d" }}/{{if (ne .Page 0)}}page/{{ .Page }}/{{end}}index.html]],
   monthly_title       = [[{{.App.Config.Params.SiteName}} :: monthly archive :: {{.Year}}.{{.Month}}]],
 
+  series_url_path     = [[articles/series/{{ .Series }}/{{if (ne .Page 0)}}page/{{ .Page }}/{{end}}index.html]],
+  series_title        = [[{{.App.Config.Params.SiteName}} :: series :: {{.Series}}]],
+
+  author_url_path     = [[articles/author/{{ .Author }}/{{if (ne .Page 0)}}page/{{ .Page }}/{{end}}index.html]],
+  author_title        = [[{{.App.Config.Params.SiteName}} :: author :: {{.Author}}]],
+
+  taxonomies          = {
+    {
+      name     = "categories",
+      header   = "categories",
+      url_path = [[articles/category/{{ .Term }}/{{if (ne .Page 0)}}page/{{ .Page }}/{{end}}index.html]],
+      title    = [[{{.App.Config.Params.SiteName}} :: category :: {{.Term}}]],
+      template = "list2",
+    },
+  },
+
   include_url_path    = [[include/{{ .Name }}]],
   feed_url_path       = [[{{ .Name }}]],
   file_url_path       = [[{{ .Path }}]],
 
   content_dir         = "src",
   output_dir          = "public_html",
+  drafts_output_dir   = "drafts_html",
   theme_dir           = "themes",
   extra_files         = {
     {src = "favicon.ico", dst = "", template = false},
@@ -48,6 +126,16 @@ config {
     {src = "CNAME", dst = "", template = false},
     {src = "profile.html", dst = "", template = true}
   },
+  -- server-side redirects from article aliases. "" disables a file.
+  redirects = {
+    netlify = "_redirects",
+    nginx   = "",
+    apache  = "",
+  },
+  prune = {
+    -- glob patterns of output paths that build --prune never deletes
+    keep = {".git", "CNAME", ".nojekyll"},
+  },
   clean = {
     "articles",
     "page",
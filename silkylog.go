@@ -5,21 +5,15 @@ import (
 	"os"
 
 	"github.com/urfave/cli"
-	lua "github.com/yuin/gopher-lua"
+	"github.com/yuin/silkylog/silkylog"
 )
 
-func createRootLState(app *application) *lua.LState {
-	L := lua.NewState()
-	app.Config = loadConfig(L)
-	luaPool.Put(L)
-	return L
-}
+const configFile = "config.lua"
 
 func main() {
 	cliapp := cli.NewApp()
-	app := newApp()
-	_app = app
-	defer luaPool.Shutdown()
+	app := silkylog.New()
+	defer app.Close()
 
 	cliapp.Name = "silkylog"
 	cliapp.Usage = "simple static site generator"
@@ -37,8 +31,7 @@ func main() {
 				},
 			},
 			Action: func(c *cli.Context) error {
-				createRootLState(app)
-				err := newsite(app, c.String("path"))
+				err := app.NewSite(c.String("path"))
 				if err != nil {
 					return cli.NewExitError(err.Error(), 1)
 				}
@@ -53,17 +46,54 @@ func main() {
 					Name:  "clean",
 					Usage: "clean all data before building my site",
 				},
+				cli.BoolFlag{
+					Name:  "full",
+					Usage: "ignore the build manifest and rebuild everything",
+				},
+				cli.BoolFlag{
+					Name:  "future",
+					Usage: "include published articles whose posted_at is in the future",
+				},
+				cli.BoolFlag{
+					Name:  "drafts",
+					Usage: "include drafts and build my site into drafts_output_dir",
+				},
+				cli.BoolFlag{
+					Name:  "prune",
+					Usage: "delete files in output_dir that were not written by the build",
+				},
+				cli.BoolFlag{
+					Name:  "prune-dry-run",
+					Usage: "report files that --prune would delete",
+				},
+				cli.BoolFlag{
+					Name:  "watch",
+					Usage: "watch sources, themes and config.lua and rebuild my site on changes",
+				},
 			},
 			Action: func(c *cli.Context) error {
-				createRootLState(app)
+				if err := app.LoadConfig(configFile); err != nil {
+					return cli.NewExitError(err.Error(), 1)
+				}
 				var err error
 				if c.Bool("clean") {
-					err = clean(app)
+					err = app.Clean(c.Bool("drafts"))
 					if err != nil {
 						return cli.NewExitError(err.Error(), 1)
 					}
 				}
-				err = build(app)
+				opts := silkylog.BuildOptions{
+					Full:        c.Bool("full"),
+					Future:      c.Bool("future"),
+					Drafts:      c.Bool("drafts"),
+					Prune:       c.Bool("prune"),
+					PruneDryRun: c.Bool("prune-dry-run"),
+				}
+				if c.Bool("watch") {
+					err = app.Watch(opts)
+				} else {
+					err = app.Build(opts)
+				}
 				if err != nil {
 					return cli.NewExitError(err.Error(), 1)
 				}
@@ -73,9 +103,17 @@ func main() {
 		{
 			Name:  "clean",
 			Usage: "clean all data",
+			Flags: []cli.Flag{
+				cli.BoolFlag{
+					Name:  "drafts",
+					Usage: "clean drafts_output_dir built by build --drafts",
+				},
+			},
 			Action: func(c *cli.Context) error {
-				createRootLState(app)
-				err := clean(app)
+				if err := app.LoadConfig(configFile); err != nil {
+					return cli.NewExitError(err.Error(), 1)
+				}
+				err := app.Clean(c.Bool("drafts"))
 				if err != nil {
 					return cli.NewExitError(err.Error(), 1)
 				}
@@ -90,14 +128,24 @@ func main() {
 					Name:  "port",
 					Usage: "server port(default 7000)",
 				},
+				cli.BoolFlag{
+					Name:  "livereload",
+					Usage: "reload browsers automatically when contents are changed",
+				},
+				cli.BoolFlag{
+					Name:  "drafts",
+					Usage: "serve drafts_output_dir built by build --drafts",
+				},
 			},
 			Action: func(c *cli.Context) error {
-				createRootLState(app)
+				if err := app.LoadConfig(configFile); err != nil {
+					return cli.NewExitError(err.Error(), 1)
+				}
 				port := c.Int("port")
 				if port == 0 {
 					port = 7000
 				}
-				err := serve(app, port)
+				err := app.Serve(port, c.Bool("livereload"), c.Bool("drafts"))
 				if err != nil {
 					return cli.NewExitError(err.Error(), 1)
 				}
@@ -116,27 +164,48 @@ func main() {
 					Name:  "port",
 					Usage: "server port(default 7000)",
 				},
+				cli.BoolFlag{
+					Name:  "livereload",
+					Usage: "reload browsers automatically when contents are changed",
+				},
 			},
 			Action: func(c *cli.Context) error {
-				createRootLState(app)
+				if err := app.LoadConfig(configFile); err != nil {
+					return cli.NewExitError(err.Error(), 1)
+				}
 				var err error
 				port := c.Int("port")
 				if port == 0 {
 					port = 7000
 				}
-				err = preview(app, port, c.String("path"))
+				err = app.Preview(port, c.String("path"), c.Bool("livereload"))
 				if err != nil {
 					return cli.NewExitError(err.Error(), 1)
 				}
 				return nil
 			},
 		},
+		{
+			Name:  "check",
+			Usage: "check links in my site",
+			Action: func(c *cli.Context) error {
+				if err := app.LoadConfig(configFile); err != nil {
+					return cli.NewExitError(err.Error(), 1)
+				}
+				if err := app.Check(); err != nil {
+					return cli.NewExitError(err.Error(), 1)
+				}
+				return nil
+			},
+		},
 		{
 			Name:  "new",
 			Usage: "create new article",
 			Action: func(c *cli.Context) error {
-				createRootLState(app)
-				err := newarticle(app)
+				if err := app.LoadConfig(configFile); err != nil {
+					return cli.NewExitError(err.Error(), 1)
+				}
+				err := app.NewArticle()
 				if err != nil {
 					return cli.NewExitError(err.Error(), 1)
 				}
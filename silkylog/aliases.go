@@ -0,0 +1,99 @@
+package silkylog
+
+import (
+	"fmt"
+	"html"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// RedirectsConfig configures server-side redirect files generated from
+// article aliases. An empty name disables the file.
+type RedirectsConfig struct {
+	// Netlify is a name of the Netlify/Cloudflare Pages _redirects file.
+	Netlify string
+	// Nginx is a name of the file included in an nginx map block.
+	Nginx string
+	// Apache is a name of the Apache .htaccess file.
+	Apache string
+}
+
+const redirectStub = `<!DOCTYPE html>
+<html>
+  <head>
+    <meta charset="utf-8">
+    <title>Redirecting&hellip;</title>
+    <link rel="canonical" href="%[1]v">
+    <meta name="robots" content="noindex">
+    <meta http-equiv="refresh" content="0; url=%[1]v">
+  </head>
+  <body>
+    <p>This page has moved to <a href="%[1]v">%[1]v</a>.</p>
+  </body>
+</html>
+`
+
+type redirect struct {
+	from string
+	to   string
+}
+
+// aliasPath returns an output path of the redirect stub for the alias.
+func (app *Application) aliasPath(alias string) string {
+	p := strings.TrimLeft(alias, "/")
+	switch {
+	case len(p) == 0 || strings.HasSuffix(p, "/"):
+		return p + "index.html"
+	case len(path.Ext(p)) != 0:
+		return p
+	case app.Config.TrimHTML:
+		return p + ".html"
+	}
+	return p + "/index.html"
+}
+
+func buildAliases(app *Application) error {
+	redirects := []redirect{}
+	odir := app.outputDir
+	for _, art := range append(append(Articles{}, app.Articles...), app.Pages...) {
+		for _, alias := range art.Aliases {
+			opath := filepath.Join(odir, filepath.FromSlash(app.aliasPath(alias)))
+			if !isSubPath(odir, opath) || opath == odir {
+				return fmt.Errorf("%v: invalid alias: %v", art.FilePath, alias)
+			}
+			app.Stats.Inc("Alias")
+			app.Debug("alias: %v -> %v", opath, art.PermlinkUrl)
+			stub := fmt.Sprintf(redirectStub, html.EscapeString(art.PermlinkUrl))
+			if err := writeOutput(app, stub, opath); err != nil {
+				return fmt.Errorf("%v: %w", art.FilePath, err)
+			}
+			redirects = append(redirects, redirect{urlEncode("/" + strings.TrimLeft(alias, "/")), art.PermlinkPath})
+		}
+	}
+	sort.Slice(redirects, func(i, j int) bool { return redirects[i].from < redirects[j].from })
+
+	cfg := app.Config.Redirects
+	for _, file := range []struct {
+		name   string
+		format func(redirect) string
+	}{
+		{cfg.Netlify, func(r redirect) string { return fmt.Sprintf("%v %v 301\n", r.from, r.to) }},
+		{cfg.Nginx, func(r redirect) string { return fmt.Sprintf("%v %v;\n", r.from, r.to) }},
+		{cfg.Apache, func(r redirect) string { return fmt.Sprintf("Redirect 301 %v %v\n", r.from, r.to) }},
+	} {
+		if len(file.name) == 0 {
+			continue
+		}
+		var buf strings.Builder
+		for _, r := range redirects {
+			buf.WriteString(file.format(r))
+		}
+		if err := writeOutput(app, buf.String(), filepath.Join(odir, file.name)); err != nil {
+			return fmt.Errorf("%v: %w", file.name, err)
+		}
+	}
+	app.Log("%d aliases", app.Stats.Get("Alias"))
+	return nil
+}
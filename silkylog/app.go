@@ -0,0 +1,575 @@
+// Package silkylog is a simple and extensible static site generator.
+package silkylog
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	htemplate "html/template"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	chtml "github.com/alecthomas/chroma/v2/formatters/html"
+	"github.com/russross/blackfriday"
+	"github.com/yuin/gluamapper"
+	"github.com/yuin/goldmark"
+	highlighting "github.com/yuin/goldmark-highlighting/v2"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/parser"
+	grenderer "github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/renderer/html"
+	lua "github.com/yuin/gopher-lua"
+)
+
+// Stats counts generated files by name.
+type Stats struct {
+	m       sync.Mutex
+	counter map[string]int
+}
+
+func newStats() *Stats {
+	return &Stats{
+		counter: map[string]int{},
+	}
+}
+
+// Inc increments the counter of the name.
+func (sts *Stats) Inc(name string) {
+	sts.m.Lock()
+	defer sts.m.Unlock()
+	sts.counter[name] = sts.counter[name] + 1
+}
+
+// Get returns the counter of the name.
+func (sts *Stats) Get(name string) int {
+	sts.m.Lock()
+	defer sts.m.Unlock()
+	return sts.counter[name]
+}
+
+// Application is a silkylog site.
+type Application struct {
+	Config     *Config
+	Stats      *Stats
+	Articles   Articles
+	Pages      Articles
+	Tags       ArticleMap
+	Years      ArticleMap
+	Months     ArticleMap
+	Taxonomies map[string]ArticleMap
+	Series     map[string]*Series
+	Authors    map[string]*Author
+	manifest   *manifest
+	sitemap    *sitemap
+
+	Logger func(*Application, string, ...interface{})
+
+	m          sync.Mutex
+	configPath string
+	luaPool    *lStatePool
+	tplcahe    map[string]*template.Template
+	htplcahe   map[string]*htemplate.Template
+
+	// outputDir is output_dir, or drafts_output_dir while building drafts.
+	outputDir string
+}
+
+// New returns a new Application. Call LoadConfig before using it.
+func New() *Application {
+	app := &Application{
+		Stats:      newStats(),
+		Articles:   []*Article{},
+		Pages:      []*Article{},
+		Tags:       make(map[string][]*Article),
+		Years:      make(map[string][]*Article),
+		Months:     make(map[string][]*Article),
+		Taxonomies: make(map[string]ArticleMap),
+		Series:     make(map[string]*Series),
+		Authors:    make(map[string]*Author),
+
+		Logger: func(app *Application, format string, args ...interface{}) {
+			nowstr := time.Now().Format(time.RFC822)
+			if len(args) > 0 {
+				fmt.Printf(nowstr+"\t"+format, args...)
+				fmt.Print("\n")
+			} else {
+				fmt.Println(nowstr + "\t" + format)
+			}
+		},
+
+		tplcahe:  make(map[string]*template.Template),
+		htplcahe: make(map[string]*htemplate.Template),
+	}
+	app.luaPool = newLStatePool(app)
+	return app
+}
+
+// LoadConfig loads the config file and the theme.lua of the configured theme.
+// Paths in the config file are relative to the current directory.
+func (app *Application) LoadConfig(path string) error {
+	app.luaPool.Shutdown()
+	app.configPath = path
+	L := lua.NewState()
+	cfg, err := app.loadConfig(L)
+	if err != nil {
+		L.Close()
+		return err
+	}
+	app.Config = cfg
+	app.luaPool.Put(L)
+	return app.compileTemplates()
+}
+
+// Close releases resources held by the Application.
+func (app *Application) Close() {
+	app.luaPool.Shutdown()
+}
+
+// Reset discards the articles and the statistics of the previous build.
+// Build calls it before loading articles.
+func (app *Application) Reset() {
+	app.Stats = newStats()
+	app.Articles = []*Article{}
+	app.Pages = []*Article{}
+	app.Tags = make(map[string][]*Article)
+	app.Years = make(map[string][]*Article)
+	app.Months = make(map[string][]*Article)
+	app.Taxonomies = make(map[string]ArticleMap)
+	app.Series = make(map[string]*Series)
+	app.Authors = make(map[string]*Author)
+	app.manifest = nil
+}
+
+// Log writes a message with the Logger.
+func (app *Application) Log(format string, args ...interface{}) {
+	app.m.Lock()
+	defer app.m.Unlock()
+	app.Logger(app, format, args...)
+}
+
+// Debug writes a message with the Logger if debug is enabled.
+func (app *Application) Debug(format string, args ...interface{}) {
+	app.m.Lock()
+	defer app.m.Unlock()
+	if app.Config.Debug {
+		app.Logger(app, format, args...)
+	}
+}
+
+// ConvertArticleText converts the article text into html and computes the
+// summary, the table of contents and the word count unless already done.
+func (app *Application) ConvertArticleText(art *Article) error {
+	art.m.Lock()
+	defer art.m.Unlock()
+	if len(art.BodyHTML) == 0 {
+		if err := app.convertArticle(art); err != nil {
+			return err
+		}
+	}
+	if art.TOC == nil {
+		lo, hi := app.tocLevels()
+		art.BodyHTML, art.TOC = buildTOC(art.BodyHTML, lo, hi)
+		app.countArticleWords(art)
+	}
+	return nil
+}
+
+const (
+	defaultWordsPerMinute    = 200
+	defaultCJKCharsPerMinute = 500
+)
+
+// countArticleWords sets the word count and the reading time of the article.
+// CJK characters are read at a different rate from words.
+func (app *Application) countArticleWords(art *Article) {
+	wpm, cpm := app.Config.WordsPerMinute, app.Config.CJKCharsPerMinute
+	if wpm <= 0 {
+		wpm = defaultWordsPerMinute
+	}
+	if cpm <= 0 {
+		cpm = defaultCJKCharsPerMinute
+	}
+	words, cjk := countWords(stripHTML(art.BodyHTML))
+	art.WordCount = words + cjk
+	minutes := float64(words)/float64(wpm) + float64(cjk)/float64(cpm)
+	art.ReadingTime = int(math.Ceil(minutes))
+	if art.WordCount > 0 && art.ReadingTime == 0 {
+		art.ReadingTime = 1
+	}
+}
+
+func (app *Application) convertArticle(art *Article) error {
+	L, err := app.luaPool.Get()
+	if err != nil {
+		return err
+	}
+	defer app.luaPool.Put(L)
+	html, err := app.convertArticleText(L, art.BodyText, art.Format)
+	if err != nil {
+		return err
+	}
+	art.BodyHTML = html
+
+	summary := art.summarySource
+	if len(summary) == 0 {
+		if idx := strings.Index(art.BodyText, moreMarker); idx > -1 {
+			summary = art.BodyText[:idx]
+		}
+	}
+	if len(summary) != 0 {
+		html, err := app.convertArticleText(L, summary, art.Format)
+		if err != nil {
+			return fmt.Errorf("summary: %w", err)
+		}
+		art.SummaryHTML = html
+		art.Summary = stripHTML(html)
+		art.HasMore = true
+		return nil
+	}
+	words := app.Config.SummaryWords
+	if words <= 0 {
+		words = defaultSummaryWords
+	}
+	art.Summary, art.HasMore = truncateWords(stripHTML(art.BodyHTML), words)
+	art.SummaryHTML = "<p>" + htemplate.HTMLEscapeString(art.Summary) + "</p>"
+	return nil
+}
+
+func (app *Application) convertArticleText(L *lua.LState, markup, format string) (string, error) {
+	processor := L.GetField(L.GetField(L.GetGlobal("CONFIG"), "markup_processors"), format)
+	if processor == lua.LNil {
+		return "", errors.New("unknown markup format: " + format)
+	}
+	if fn, ok := processor.(*lua.LFunction); ok {
+		L.Push(fn)
+		L.Push(lua.LString(markup))
+		if err := L.PCall(1, 1, nil); err != nil {
+			return "", err
+		}
+		return luaPop(L).String(), nil
+	}
+	_opts, ok := processor.(*lua.LTable)
+	if !ok {
+		return "", errors.New("markup_processors must be a function or table")
+	}
+	mapperOpts := gluamapper.Option{
+		NameFunc: gluamapper.Id,
+		TagName:  "gluamapper",
+	}
+	opts := gluamapper.ToGoValue(_opts, mapperOpts).(map[interface{}]interface{})
+
+	switch format {
+	case ".md":
+		switch opts["name"].(string) {
+		case "blackfriday":
+			lhtmlopts := opts["htmlopts"]
+			lexts := opts["exts"]
+			htmlopts, err := strListToOption("htmlopts", lhtmlopts, 0, strToBfHTMLOpts)
+			if err != nil {
+				return "", err
+			}
+			exts, err := strListToOption("exts", lexts, 0, strToBfExts)
+			if err != nil {
+				return "", err
+			}
+			rd := blackfriday.HtmlRenderer(htmlopts, "", "")
+			html := string(blackfriday.Markdown(([]byte)(markup), rd, exts))
+			return strings.Replace(html, "<pre><code", "<pre class=\"prettyprint\"><code", -1), nil
+		default:
+			lhtmlopts := opts["htmlopts"]
+			lmdopts := opts["mdopts"]
+			lexts := opts["exts"]
+
+			htmlopts, err := strListToFuncOption[grenderer.Option]("htmlopts",
+				lhtmlopts, []grenderer.Option{}, strToGoldmarkHTMLOpts)
+			if err != nil {
+				return "", err
+			}
+			mdopts, err := strListToFuncOption[parser.Option]("mdopts", lmdopts, []parser.Option{}, strToGoldmarkMDOpts)
+			if err != nil {
+				return "", err
+			}
+			exts, err := strListToFuncOption[goldmark.Extender]("mdopts", lexts, []goldmark.Extender{}, strToGoldmarkExts)
+			if err != nil {
+				return "", err
+			}
+			markdown := goldmark.New(
+				goldmark.WithParserOptions(mdopts...),
+				goldmark.WithRendererOptions(htmlopts...),
+				goldmark.WithExtensions(exts...),
+			)
+			var out bytes.Buffer
+			err = markdown.Convert([]byte(markup), &out)
+			if err != nil {
+				return "", err
+			}
+			return out.String(), nil
+		}
+	}
+	return "", errors.New("no builtin processors found for '" + format + "'")
+}
+
+func (app *Application) titleTemplate(name string) (*htemplate.Template, error) {
+	app.m.Lock()
+	defer app.m.Unlock()
+	tpl, ok := app.htplcahe[name]
+	if !ok {
+		return nil, errors.New(name + " is invalid title")
+	}
+	return tpl, nil
+}
+
+func (app *Application) pathTemplate(name string) (*template.Template, error) {
+	app.m.Lock()
+	defer app.m.Unlock()
+	tpl, ok := app.tplcahe[name]
+	if !ok {
+		return nil, errors.New(name + " is invalid url_path")
+	}
+	return tpl, nil
+}
+
+// Title renders the title of the name like "Article" or "Tag" with the data.
+func (app *Application) Title(name string, data interface{}) (string, error) {
+	tpl, err := app.titleTemplate(name)
+	if err != nil {
+		return "", err
+	}
+	title, err := execHtemplate(tpl, data)
+	if err != nil {
+		return "", fmt.Errorf("%v title: %w", name, err)
+	}
+	return title, nil
+}
+
+// Path renders the output path of the name like "Article" or "Tag" with the
+// data. The path is relative to the output directory.
+func (app *Application) Path(name string, data interface{}) (string, error) {
+	tpl, err := app.pathTemplate(name)
+	if err != nil {
+		return "", err
+	}
+	path, err := execTemplate(tpl, data)
+	if err != nil {
+		return "", fmt.Errorf("%v url_path: %w", name, err)
+	}
+	return path, nil
+}
+
+func (app *Application) relURL(name string, data interface{}) (string, error) {
+	path, err := app.Path(name, data)
+	if err != nil {
+		return "", err
+	}
+	url := strings.TrimSuffix(path, "index.html")
+	if app.Config.TrimHTML {
+		return strings.TrimSuffix(url, ".html"), nil
+	}
+	return urlEncode(url), nil
+}
+
+// Url returns an absolute URL path of the name rendered with the data.
+func (app *Application) Url(name string, data interface{}) (string, error) {
+	url, err := app.relURL(name, data)
+	if err != nil {
+		return "", err
+	}
+	return "/" + url, nil
+}
+
+// FullURL returns an URL of the name rendered with the data, including site_url.
+func (app *Application) FullURL(name string, data interface{}) (string, error) {
+	url, err := app.relURL(name, data)
+	if err != nil {
+		return "", err
+	}
+	return app.Config.SiteUrl + url, nil
+}
+
+func (app *Application) compileTemplates() (err error) {
+	defer func() {
+		v := recover()
+		if v != nil {
+			err = v.(error)
+		}
+	}()
+	// start from empty caches so that taxonomies removed from the config
+	// (e.g. while watching) no longer resolve
+	app.tplcahe = make(map[string]*template.Template)
+	app.htplcahe = make(map[string]*htemplate.Template)
+	rv := reflect.ValueOf(app.Config).Elem()
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		name := rt.Field(i).Name
+		if strings.HasSuffix(name, "UrlPath") {
+			app.tplcahe[strings.TrimSuffix(name, "UrlPath")] = template.Must(template.New("").Parse(rv.Field(i).String()))
+		} else if strings.HasSuffix(name, "Title") {
+			app.htplcahe[strings.TrimSuffix(name, "Title")] = htemplate.Must(htemplate.New("").Parse(rv.Field(i).String()))
+		}
+	}
+	return app.compileTaxonomyTemplates()
+}
+
+// LoadArticles loads articles that have the status. The status is a comma
+// separated list like "published,draft".
+func (app *Application) LoadArticles(status string) error {
+	return app.loadArticles(status, nil)
+}
+
+// loadArticles loads articles that have the status and for which filter
+// returns true. A nil filter accepts all articles.
+func (app *Application) loadArticles(status string, filter func(*Article) bool) error {
+	arts, errs, err := loadContents(filepath.Join(app.Config.ContentDir, "articles"), app.LoadArticle, status, filter)
+	if err != nil {
+		return err
+	}
+	if len(errs) != 0 {
+		return fmt.Errorf("failed to load %d articles:\n%w", len(errs), errs)
+	}
+	app.Articles = arts
+	sort.Sort(app.Articles)
+	app.Tags = make(ArticleMap)
+	app.Years = make(ArticleMap)
+	app.Months = make(ArticleMap)
+	app.Taxonomies = make(map[string]ArticleMap)
+
+	for _, art := range app.Articles {
+		for _, tag := range art.Tags {
+			app.Tags.Add(tag, art)
+		}
+		iyear, imonth := art.PostedAt.Year(), art.PostedAt.Month()
+		syear, smonth := fmt.Sprintf("%04d", iyear), fmt.Sprintf("%04d%02d", iyear, imonth)
+		app.Years.Add(syear, art)
+		app.Months.Add(smonth, art)
+		for _, tx := range app.Config.Taxonomies {
+			am, ok := app.Taxonomies[tx.Name]
+			if !ok {
+				am = make(ArticleMap)
+				app.Taxonomies[tx.Name] = am
+			}
+			for _, term := range art.Terms(tx.Name) {
+				am.Add(term, art)
+			}
+		}
+	}
+	app.computeRelated()
+	app.computeSeries()
+	app.computeAuthors()
+	app.linkArticles()
+	return nil
+}
+
+// loadContents loads content files under the basedir with load and returns
+// ones that have the status and for which filter returns true. Errors of
+// each file are collected into errs.
+func loadContents(basedir string, load func(string) (*Article, error), status string,
+	filter func(*Article) bool) (arts Articles, errs multiError, err error) {
+	err = filepath.Walk(basedir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		basename := filepath.Base(path)
+		if info.IsDir() {
+			if strings.HasPrefix(basename, ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if strings.HasPrefix(basename, ".") {
+			return nil
+		}
+		art, err := load(path)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%v: %w", path, err))
+			return nil
+		}
+		if status != "*" && !strings.Contains(status, art.Status) {
+			return nil
+		}
+		if filter == nil || filter(art) {
+			arts = append(arts, art)
+		}
+		return nil
+	})
+	return
+}
+
+func (app *Application) openEditor(path string) error {
+	edcopy := make([]string, len(app.Config.Editor)+1)
+	copy(edcopy, app.Config.Editor)
+	edcopy[len(edcopy)-1] = path
+	cmd := exec.Command(edcopy[0], edcopy[1:]...)
+	return cmd.Start()
+}
+
+var strToGoldmarkHTMLOpts = map[string]grenderer.Option{
+	"unsafe": html.WithUnsafe(),
+}
+
+var strToGoldmarkMDOpts = map[string]parser.Option{
+	"autoHeadingID": parser.WithAutoHeadingID(),
+	"attribute":     parser.WithAttribute(),
+}
+
+var strToGoldmarkExts = map[string]goldmark.Extender{
+	"table":          extension.Table,
+	"strikethrough":  extension.Strikethrough,
+	"linkify":        extension.Linkify,
+	"taskList":       extension.TaskList,
+	"gfm":            extension.GFM,
+	"definitionList": extension.DefinitionList,
+	"footnote":       extension.Footnote,
+	"typographer":    extension.Typographer,
+	"cjk":            extension.CJK,
+	"highlighting": highlighting.NewHighlighting(
+		highlighting.WithStyle("monokai"),
+		highlighting.WithGuessLanguage(true),
+		highlighting.WithFormatOptions(
+			chtml.WithLineNumbers(true),
+		),
+	),
+}
+
+var strToBfHTMLOpts = map[string]int{
+	"HTML_SKIP_HTML":                 blackfriday.HTML_SKIP_HTML,
+	"HTML_SKIP_STYLE":                blackfriday.HTML_SKIP_STYLE,
+	"HTML_SKIP_IMAGES":               blackfriday.HTML_SKIP_IMAGES,
+	"HTML_SKIP_LINKS":                blackfriday.HTML_SKIP_LINKS,
+	"HTML_SAFELINK":                  blackfriday.HTML_SAFELINK,
+	"HTML_NOFOLLOW_LINKS":            blackfriday.HTML_NOFOLLOW_LINKS,
+	"HTML_HREF_TARGET_BLANK":         blackfriday.HTML_HREF_TARGET_BLANK,
+	"HTML_TOC":                       blackfriday.HTML_TOC,
+	"HTML_OMIT_CONTENTS":             blackfriday.HTML_OMIT_CONTENTS,
+	"HTML_COMPLETE_PAGE":             blackfriday.HTML_COMPLETE_PAGE,
+	"HTML_USE_XHTML":                 blackfriday.HTML_USE_XHTML,
+	"HTML_USE_SMARTYPANTS":           blackfriday.HTML_USE_SMARTYPANTS,
+	"HTML_SMARTYPANTS_FRACTIONS":     blackfriday.HTML_SMARTYPANTS_FRACTIONS,
+	"HTML_SMARTYPANTS_LATEX_DASHES":  blackfriday.HTML_SMARTYPANTS_LATEX_DASHES,
+	"HTML_SMARTYPANTS_ANGLED_QUOTES": blackfriday.HTML_SMARTYPANTS_ANGLED_QUOTES,
+	"HTML_FOOTNOTE_RETURN_LINKS":     blackfriday.HTML_FOOTNOTE_RETURN_LINKS,
+}
+
+var strToBfExts = map[string]int{
+	"EXTENSION_NO_INTRA_EMPHASIS":          blackfriday.EXTENSION_NO_INTRA_EMPHASIS,
+	"EXTENSION_TABLES":                     blackfriday.EXTENSION_TABLES,
+	"EXTENSION_FENCED_CODE":                blackfriday.EXTENSION_FENCED_CODE,
+	"EXTENSION_AUTOLINK":                   blackfriday.EXTENSION_AUTOLINK,
+	"EXTENSION_STRIKETHROUGH":              blackfriday.EXTENSION_STRIKETHROUGH,
+	"EXTENSION_LAX_HTML_BLOCKS":            blackfriday.EXTENSION_LAX_HTML_BLOCKS,
+	"EXTENSION_SPACE_HEADERS":              blackfriday.EXTENSION_SPACE_HEADERS,
+	"EXTENSION_HARD_LINE_BREAK":            blackfriday.EXTENSION_HARD_LINE_BREAK,
+	"EXTENSION_TAB_SIZE_EIGHT":             blackfriday.EXTENSION_TAB_SIZE_EIGHT,
+	"EXTENSION_FOOTNOTES":                  blackfriday.EXTENSION_FOOTNOTES,
+	"EXTENSION_NO_EMPTY_LINE_BEFORE_BLOCK": blackfriday.EXTENSION_NO_EMPTY_LINE_BEFORE_BLOCK,
+	"EXTENSION_HEADER_IDS":                 blackfriday.EXTENSION_HEADER_IDS,
+	"EXTENSION_TITLEBLOCK":                 blackfriday.EXTENSION_TITLEBLOCK,
+	"EXTENSION_AUTO_HEADER_IDS":            blackfriday.EXTENSION_AUTO_HEADER_IDS,
+}
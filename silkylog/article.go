@@ -0,0 +1,457 @@
+package silkylog
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	lua "github.com/yuin/gopher-lua"
+)
+
+// Article is a blog article.
+type Article struct {
+	m         sync.Mutex
+	FilePath  string
+	Format    string
+	Title     string
+	Slug      string
+	BodyText  string
+	BodyHTML  string
+	Status    string
+	Tags      []string
+	Aliases   []string
+	Authors   []*Author
+	PostedAt  time.Time
+	UpdatedAt time.Time
+	Params    map[string]interface{}
+
+	// headers are raw values of all headers including ones mapped onto fields.
+	headers map[string]interface{}
+	terms   map[string][]string
+
+	Summary     string
+	SummaryHTML string
+	HasMore     bool
+	TOC         TOC
+	WordCount   int
+	ReadingTime int
+	Related     Articles
+
+	Series      *Series
+	SeriesOrder int
+	SeriesPart  int
+	SeriesPrev  *Article
+	SeriesNext  *Article
+
+	seriesName string
+
+	// Prev is the article posted before this article, Next is the one posted after.
+	Prev *Article
+	Next *Article
+
+	tagPrev map[string]*Article
+	tagNext map[string]*Article
+
+	summarySource string
+
+	PermlinkPath string
+	PermlinkUrl  string
+}
+
+// Articles is a list of articles sorted by posted date in descending order.
+type Articles []*Article
+
+// Len implements sort.Interface.
+func (a Articles) Len() int { return len(a) }
+
+// Swap implements sort.Interface.
+func (a Articles) Swap(i, j int) { a[i], a[j] = a[j], a[i] }
+
+// Less implements sort.Interface. Newer articles come first.
+func (a Articles) Less(i, j int) bool { return a[i].PostedAt.Unix() > a[j].PostedAt.Unix() }
+
+// SubList returns a[i:j]. Negative indexes count from the end and indexes
+// out of range are clamped.
+func (a Articles) SubList(i, j int) Articles {
+	if i < 0 {
+		i = len(a) + i
+	}
+	if j < 0 {
+		j = len(a) + j + 1
+	}
+	i = intMax(intMin(i, len(a)-1), 0)
+	j = intMax(intMin(j, len(a)), 0)
+	return Articles(a[i:j])
+}
+
+// ArticleMap groups articles by a key like tags.
+type ArticleMap map[string][]*Article
+
+// Add appends the article to the list of the key.
+func (am ArticleMap) Add(key string, art *Article) {
+	_, ok := am[key]
+	if !ok {
+		am[key] = make([]*Article, 0, 10)
+	}
+	am[key] = append(am[key], art)
+}
+
+// SortedMapKeys returns keys of the map in ascending order, or in descending
+// order if reverse is true.
+func (am ArticleMap) SortedMapKeys(reverse bool) []string {
+	keys := []string{}
+	for key := range am {
+		keys = append(keys, key)
+	}
+	if !reverse {
+		sort.Strings(keys)
+	} else {
+		sort.Sort(sort.Reverse(sort.StringSlice(keys)))
+	}
+	return keys
+}
+
+// LoadArticle loads the article at the path.
+func (app *Application) LoadArticle(path string) (*Article, error) {
+	art, err := app.loadArticleFile(path)
+	if err != nil {
+		return nil, err
+	}
+	completeArticleHeader(art)
+	app.setTerms(art)
+	if err := app.setPermlink(art, "Article"); err != nil {
+		return nil, err
+	}
+	return art, nil
+}
+
+// setPermlink sets permlinks of the article with the url_path of the name.
+func (app *Application) setPermlink(art *Article, name string) error {
+	url, err := app.Url(name, art)
+	if err != nil {
+		return err
+	}
+	art.PermlinkPath = url
+	art.PermlinkUrl = app.Config.SiteUrl + strings.TrimLeft(url, "/")
+	return nil
+}
+
+// loadArticleFile reads headers and the text of the article file.
+func (app *Application) loadArticleFile(path string) (*Article, error) {
+	fp, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = fp.Close()
+	}()
+	art := &Article{}
+	art.FilePath = path
+	art.Format = filepath.Ext(path)
+	art.Tags = []string{}
+	art.Aliases = []string{}
+	art.Params = make(map[string]interface{})
+	buf := []string{}
+	btext, err := io.ReadAll(fp)
+	if err != nil {
+		return nil, err
+	}
+	text := string(btext)
+	firstline := strings.TrimSpace(strings.SplitN(text, "\n", 2)[0])
+	if firstline == yamlFrontMatterDelimiter || firstline == tomlFrontMatterDelimiter {
+		rest, err := parseFrontMatter(app, art, firstline, text)
+		if err != nil {
+			return nil, err
+		}
+		buf = strings.Split(strings.TrimPrefix(strings.TrimPrefix(rest, "\r"), "\n"), "\n")
+	} else {
+		body := false
+		for _, line := range strings.Split(text, "\n") {
+			var err error
+			if len(line) == 0 && !body {
+				body = true
+				continue
+			}
+			if body {
+				buf = append(buf, string(line))
+			} else {
+				err = parseArticleHeader(app, art, string(line))
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	art.BodyText = strings.Join(buf, "\n")
+	return art, nil
+}
+
+func parseArticleHeader(app *Application, art *Article, line string) error {
+	names := strings.Split(line, ":")
+	if len(names) < 3 {
+		return errors.New("invalid header: " + line)
+	}
+	name, value := names[1], strings.TrimSpace(strings.Join(names[2:], ":"))
+	art.addHeader(name, value)
+	return setArticleHeader(app, art, name, value)
+}
+
+// addHeader records the raw header value. Values of a header given more than
+// once are collected into a list.
+func (art *Article) addHeader(name string, value interface{}) {
+	if art.headers == nil {
+		art.headers = make(map[string]interface{})
+	}
+	prev, ok := art.headers[name]
+	if !ok {
+		art.headers[name] = value
+		return
+	}
+	values, ok := prev.([]interface{})
+	if !ok {
+		values = []interface{}{prev}
+	}
+	art.headers[name] = append(values, value)
+}
+
+// articleHeaders are header names that are mapped onto Article fields. Other
+// headers are stored in Article.Params.
+var articleHeaders = map[string]bool{
+	"title":        true,
+	"slug":         true,
+	"status":       true,
+	"tags":         true,
+	"posted_at":    true,
+	"updated_at":   true,
+	"summary":      true,
+	"aliases":      true,
+	"series":       true,
+	"series_order": true,
+}
+
+// moreMarker separates the summary from the rest of the article text.
+const moreMarker = "<!--more-->"
+
+const defaultSummaryWords = 50
+
+func setArticleHeader(app *Application, art *Article, name, value string) error {
+	switch name {
+	case "title":
+		art.Title = value
+	case "slug":
+		art.Slug = value
+	case "summary":
+		art.summarySource = value
+	case "status":
+		if value != "draft" && value != "published" {
+			return errors.New("invalid status: " + value)
+		}
+		art.Status = value
+	case "tags":
+		for _, tag := range strings.Split(value, ",") {
+			if tag = strings.TrimSpace(tag); len(tag) != 0 {
+				art.Tags = append(art.Tags, tag)
+			}
+		}
+	case "aliases":
+		for _, alias := range strings.Split(value, ",") {
+			if alias = strings.TrimSpace(alias); len(alias) != 0 {
+				art.Aliases = append(art.Aliases, alias)
+			}
+		}
+	case "series":
+		art.seriesName = value
+	case "series_order":
+		order, err := strconv.Atoi(value)
+		if err != nil {
+			return errors.New("invalid series_order: " + value)
+		}
+		art.SeriesOrder = order
+	case "posted_at":
+		t, err := parseArticleTime(app, value)
+		if err != nil {
+			return errors.New("invalid posted_at: " + err.Error())
+		}
+		art.PostedAt = t
+	case "updated_at":
+		t, err := parseArticleTime(app, value)
+		if err != nil {
+			return errors.New("invalid updated_at:" + err.Error())
+		}
+		art.UpdatedAt = t
+	case "author":
+		// every author of repeated headers, like the author pages
+		art.Params[name] = art.headerTerms(name)
+	default:
+		art.Params[name] = value
+	}
+	return nil
+}
+
+const articleTimeFormat = "2006-01-02 15:04:05"
+
+func parseArticleTime(app *Application, value string) (time.Time, error) {
+	loc, err := app.Config.Location()
+	if err != nil {
+		return time.Time{}, err
+	}
+	t, err := time.ParseInLocation(articleTimeFormat, value, loc)
+	if err == nil {
+		return t, nil
+	}
+	if t, err2 := time.Parse(time.RFC3339, value); err2 == nil {
+		return t.In(loc), nil
+	}
+	for _, layout := range []string{"2006-01-02T15:04:05", "2006-01-02"} {
+		if t, err2 := time.ParseInLocation(layout, value, loc); err2 == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, err
+}
+
+func completeArticleHeader(art *Article) {
+	if len(art.Slug) == 0 {
+		basename := filepath.Base(art.FilePath)
+		match := regexp.MustCompile(`(\d+_)(.*)\.(\w+)`).FindStringSubmatch(basename)
+		if len(match) > 0 {
+			art.Slug = match[2]
+		} else {
+			art.Slug = art.Title
+		}
+	}
+
+	if art.UpdatedAt.Year() == 1 {
+		art.UpdatedAt = art.PostedAt
+	}
+}
+
+// PrevInTag returns the article with the tag posted before this article.
+func (art *Article) PrevInTag(tag string) *Article {
+	return art.tagPrev[tag]
+}
+
+// NextInTag returns the article with the tag posted after this article.
+func (art *Article) NextInTag(tag string) *Article {
+	return art.tagNext[tag]
+}
+
+// linkArticles links neighbouring articles of the loaded articles, which are
+// sorted by posted date in descending order.
+func (app *Application) linkArticles() {
+	for i, art := range app.Articles {
+		art.Prev, art.Next = nil, nil
+		art.tagPrev = map[string]*Article{}
+		art.tagNext = map[string]*Article{}
+		if i > 0 {
+			art.Next = app.Articles[i-1]
+		}
+		if i+1 < len(app.Articles) {
+			art.Prev = app.Articles[i+1]
+		}
+	}
+	for tag, arts := range app.Tags {
+		for i, art := range arts {
+			if i > 0 {
+				art.tagNext[tag] = arts[i-1]
+			}
+			if i+1 < len(arts) {
+				art.tagPrev[tag] = arts[i+1]
+			}
+		}
+	}
+}
+
+// IsDraft returns true if the article is a draft.
+func (art *Article) IsDraft() bool {
+	return art.Status == "draft"
+}
+
+// ToLua converts the article into a Lua table for theme Lua functions.
+func (art *Article) ToLua(L *lua.LState) *lua.LTable {
+	tb := art.toLua(L)
+	related := L.NewTable()
+	for _, rart := range art.Related {
+		// related articles do not include their related articles
+		related.Append(rart.toLua(L))
+	}
+	tb.RawSetString("related", related)
+	if art.Prev != nil {
+		tb.RawSetString("prev", art.Prev.toLua(L))
+	}
+	if art.Next != nil {
+		tb.RawSetString("next", art.Next.toLua(L))
+	}
+	if art.Series != nil {
+		tb.RawSetString("series", lua.LString(art.Series.Name))
+		tb.RawSetString("series_part", lua.LNumber(art.SeriesPart))
+		if art.SeriesPrev != nil {
+			tb.RawSetString("series_prev", art.SeriesPrev.toLua(L))
+		}
+		if art.SeriesNext != nil {
+			tb.RawSetString("series_next", art.SeriesNext.toLua(L))
+		}
+	}
+	return tb
+}
+
+func (art *Article) toLua(L *lua.LState) *lua.LTable {
+	tb := L.NewTable()
+	tb.RawSetString("file_path", lua.LString(art.FilePath))
+	tb.RawSetString("format", lua.LString(art.Format))
+	tb.RawSetString("title", lua.LString(art.Title))
+	tb.RawSetString("slug", lua.LString(art.Slug))
+	tb.RawSetString("body_text", lua.LString(art.BodyText))
+	tb.RawSetString("body_html", lua.LString(art.BodyHTML))
+	tb.RawSetString("summary", lua.LString(art.Summary))
+	tb.RawSetString("summary_html", lua.LString(art.SummaryHTML))
+	tb.RawSetString("has_more", lua.LBool(art.HasMore))
+	tb.RawSetString("toc", art.TOC.ToLua(L))
+	tb.RawSetString("word_count", lua.LNumber(art.WordCount))
+	tb.RawSetString("reading_time", lua.LNumber(art.ReadingTime))
+	tb.RawSetString("status", lua.LString(art.Status))
+	tb.RawSetString("is_draft", lua.LBool(art.IsDraft()))
+	tags := L.NewTable()
+	for _, tag := range art.Tags {
+		tags.Append(lua.LString(tag))
+	}
+	tb.RawSetString("tags", tags)
+	aliases := L.NewTable()
+	for _, alias := range art.Aliases {
+		aliases.Append(lua.LString(alias))
+	}
+	tb.RawSetString("aliases", aliases)
+	authors := L.NewTable()
+	for _, author := range art.Authors {
+		tb := L.NewTable()
+		tb.RawSetString("name", lua.LString(author.Name))
+		tb.RawSetString("bio", lua.LString(author.Bio))
+		tb.RawSetString("avatar", lua.LString(author.Avatar))
+		tb.RawSetString("url", lua.LString(author.URL))
+		tb.RawSetString("email", lua.LString(author.Email))
+		authors.Append(tb)
+	}
+	tb.RawSetString("authors", authors)
+	terms := L.NewTable()
+	for name, values := range art.terms {
+		tb := L.NewTable()
+		for _, term := range values {
+			tb.Append(lua.LString(term))
+		}
+		terms.RawSetString(name, tb)
+	}
+	tb.RawSetString("terms", terms)
+	tb.RawSetString("posted_at", timeToLuaTable(L, art.PostedAt))
+	tb.RawSetString("updated_at", timeToLuaTable(L, art.UpdatedAt))
+	tb.RawSetString("params", goToLua(L, art.Params))
+	tb.RawSetString("permlink_path", lua.LString(art.PermlinkPath))
+	tb.RawSetString("permlink_url", lua.LString(art.PermlinkUrl))
+	return tb
+}
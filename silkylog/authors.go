@@ -0,0 +1,142 @@
+package silkylog
+
+import (
+	"errors"
+)
+
+// AuthorConfig declares an author referred by the author header of articles.
+type AuthorConfig struct {
+	// Name is a name of the author used in the author header.
+	Name   string
+	Bio    string
+	Avatar string
+	URL    string
+	Email  string
+}
+
+// Author is an author and articles written by the author.
+type Author struct {
+	AuthorConfig
+	Articles Articles
+}
+
+func (cfg *Config) checkAuthors() error {
+	seen := map[string]bool{}
+	for _, author := range cfg.Authors {
+		if len(author.Name) == 0 {
+			return errors.New("authors: name is required")
+		}
+		if seen[author.Name] {
+			return errors.New("authors: duplicated name: " + author.Name)
+		}
+		seen[author.Name] = true
+	}
+	return nil
+}
+
+// computeAuthors groups the loaded articles by the author header.
+func (app *Application) computeAuthors() {
+	app.Authors = make(map[string]*Author)
+	for _, cfg := range app.Config.Authors {
+		app.Authors[cfg.Name] = &Author{AuthorConfig: cfg, Articles: Articles{}}
+	}
+	for _, art := range app.Articles {
+		app.setAuthors(art)
+		for _, author := range art.Authors {
+			author.Articles = append(author.Articles, art)
+		}
+	}
+}
+
+// setAuthors sets authors named by the author header to the article or page.
+// Authors that are not declared in the config have only a name.
+func (app *Application) setAuthors(art *Article) {
+	art.Authors = []*Author{}
+	for _, name := range art.headerTerms("author") {
+		author, ok := app.Authors[name]
+		if !ok {
+			author = &Author{AuthorConfig: AuthorConfig{Name: name}, Articles: Articles{}}
+			app.Authors[name] = author
+		}
+		art.Authors = append(art.Authors, author)
+	}
+}
+
+// articleAuthors returns authors of the article, or the site author if the
+// article has no author header.
+func (app *Application) articleAuthors(art *Article) []AuthorConfig {
+	authors := []AuthorConfig{}
+	for _, author := range art.Authors {
+		authors = append(authors, author.AuthorConfig)
+	}
+	if len(authors) == 0 {
+		site := app.feedAuthor()
+		if len(site.Name) != 0 {
+			authors = append(authors, AuthorConfig{Name: site.Name, Avatar: site.Avatar, URL: site.URL, Email: site.Email})
+		}
+	}
+	return authors
+}
+
+// JSONLD returns schema.org BlogPosting data of the article. Templates
+// output it in a script element of type application/ld+json.
+func (app *Application) JSONLD(art *Article) map[string]interface{} {
+	authors := []map[string]interface{}{}
+	for _, author := range app.articleAuthors(art) {
+		person := map[string]interface{}{"@type": "Person", "name": author.Name}
+		if len(author.URL) != 0 {
+			person["url"] = author.URL
+		}
+		if len(author.Avatar) != 0 {
+			person["image"] = author.Avatar
+		}
+		authors = append(authors, person)
+	}
+	ld := map[string]interface{}{
+		"@context":         "https://schema.org",
+		"@type":            "BlogPosting",
+		"headline":         art.Title,
+		"url":              art.PermlinkUrl,
+		"mainEntityOfPage": art.PermlinkUrl,
+		"datePublished":    art.PostedAt.Format("2006-01-02T15:04:05Z07:00"),
+		"dateModified":     art.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		"author":           authors,
+	}
+	if len(art.Summary) != 0 {
+		ld["description"] = art.Summary
+	}
+	if len(art.Tags) != 0 {
+		ld["keywords"] = art.Tags
+	}
+	return ld
+}
+
+// buildAuthors renders archive pages of authors who wrote articles.
+func buildAuthors(app *Application, renderer *renderer) error {
+	if len(app.Config.AuthorUrlPath) == 0 {
+		return nil
+	}
+	terms := ArticleMap{}
+	for name, author := range app.Authors {
+		if len(author.Articles) != 0 {
+			terms[name] = author.Articles
+		}
+	}
+	if err := buildTermLists(app, renderer, app.themePage("author", "list2"), "Author", "Author", terms,
+		func(vm *viewModel, name string) {
+			vm.Author = app.Authors[name]
+		}); err != nil {
+		return err
+	}
+	app.Log("%d author pages", app.Stats.Get("Author"))
+	return nil
+}
+
+// AuthorUrl returns an URL of the archive of the author. Templates get an
+// empty string for sites without author_url_path, so they can hide the link.
+func (app *Application) AuthorUrl(name string) (string, error) {
+	if len(app.Config.AuthorUrlPath) == 0 {
+		return "", nil
+	}
+	return app.Url("Author", H("Author", name, "Page", 0))
+}
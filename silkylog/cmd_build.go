@@ -0,0 +1,436 @@
+package silkylog
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"text/template"
+	"time"
+)
+
+func buildTemplate(app *Application, renderer *renderer, tpl *template.Template, dir, counter string) error {
+	basedir := filepath.Join(app.Config.ThemeDir, app.Config.Theme, dir)
+	lst, err := os.ReadDir(basedir)
+	if err != nil {
+		return err
+	}
+	viewmodel := newViewModel(app, "", nil)
+	for _, item := range lst {
+		app.Debug("file(%v): %v", dir, item.Name())
+		app.Stats.Inc(counter)
+		txt, err := renderer.RenderType(app, item.Name(), dir, viewmodel)
+		if err != nil {
+			return fmt.Errorf("%v/%v : %w", dir, item.Name(), err)
+		}
+		path, err2 := execTemplate(tpl, map[string]string{"Name": item.Name()})
+		if err2 != nil {
+			return fmt.Errorf("%v/%v : %w", dir, item.Name(), err2)
+		}
+		if err := writeOutput(app, txt, filepath.Join(app.outputDir, path)); err != nil {
+			return fmt.Errorf("%v/%v : %w", dir, item.Name(), err)
+		}
+	}
+	return nil
+}
+
+func buildList(app *Application, renderer *renderer, lst, name string, arts []*Article,
+	dc func() map[any]any, vu func(*viewModel)) error {
+	brek := false
+	for page := 1; !brek; page++ {
+		app.Stats.Inc(name)
+		data := dc()
+		data["Page"] = page
+		path, err := app.Path(name, data)
+		if err != nil {
+			return err
+		}
+		app.Debug("article list: %v", path)
+		title, err := app.Title(name, data)
+		if err != nil {
+			return fmt.Errorf("%v: %w", path, err)
+		}
+		vm := newViewModel(app, title, nil)
+		vu(vm)
+		step := app.Config.Pagination2
+		if lst == "list1" {
+			step = app.Config.Pagination1
+		}
+		brek = vm.SetPage(page, step, arts, data, name)
+		html, err := renderer.RenderPage(app, lst, vm)
+		if err != nil {
+			return fmt.Errorf("%v: %w", path, err)
+		}
+		if err := writeOutput(app, html, filepath.Join(app.outputDir, path)); err != nil {
+			return fmt.Errorf("%v: %w", path, err)
+		}
+		lastmod := time.Time{}
+		for _, art := range vm.Articles {
+			if art.UpdatedAt.After(lastmod) {
+				lastmod = art.UpdatedAt
+			}
+		}
+
+		if page == 1 {
+			data["Page"] = 0
+			indexpath, err := app.Path(name, data)
+			if err != nil {
+				return fmt.Errorf("%v: %w", path, err)
+			}
+			if err := writeOutput(app, html, filepath.Join(app.outputDir, indexpath)); err != nil {
+				return fmt.Errorf("%v: %w", path, err)
+			}
+			app.sitemap.Add(app, indexpath, lastmod)
+		} else {
+			app.sitemap.Add(app, path, lastmod)
+		}
+	}
+	return nil
+}
+
+func buildArticle(app *Application, renderer *renderer, art *Article, errch chan error) {
+	app.Stats.Inc("Article")
+	app.Debug("article: %v", art.FilePath)
+	if app.manifest.RestoreHTML(art) {
+		app.Stats.Inc("Cached")
+	}
+	if err := app.ConvertArticleText(art); err != nil {
+		errch <- fmt.Errorf("%v: %w", art.FilePath, err)
+		return
+	}
+	app.manifest.AddSource(art)
+	html, err := app.render(renderer, art)
+	if err != nil {
+		errch <- fmt.Errorf("%v: %w", art.FilePath, err)
+		return
+	}
+	path, err := app.Path("Article", art)
+	if err != nil {
+		errch <- fmt.Errorf("%v: %w", art.FilePath, err)
+		return
+	}
+	if err := writeOutput(app, html, filepath.Join(app.outputDir, path)); err != nil {
+		errch <- fmt.Errorf("%v: %w", art.FilePath, err)
+		return
+	}
+	app.sitemap.Add(app, path, art.UpdatedAt)
+}
+
+func writeOutput(app *Application, data, path string) error {
+	written, err := app.manifest.WriteFile(data, path)
+	if err == nil && !written {
+		app.Stats.Inc("Unchanged")
+	}
+	return err
+}
+
+func (app *Application) render(renderer *renderer, art *Article) (string, error) {
+	title, err := app.Title("Article", H("App", app, "Article", art))
+	if err != nil {
+		return "", err
+	}
+	return renderer.RenderPage(app, "article", newViewModel(app, title, art))
+}
+
+// Render converts the article text and renders the article page.
+func (app *Application) Render(art *Article) (string, error) {
+	if err := app.ConvertArticleText(art); err != nil {
+		return "", err
+	}
+	return app.render(newRenderer(), art)
+}
+
+// BuildOptions are options for Build.
+type BuildOptions struct {
+	// Full ignores the build manifest and rebuilds everything.
+	Full bool
+	// Future includes published articles whose posted_at is in the future.
+	Future bool
+	// Drafts includes draft articles and writes the site into drafts_output_dir.
+	Drafts bool
+	// Prune deletes files in the output directory that were not written by the build.
+	Prune bool
+	// PruneDryRun reports files that Prune would delete without deleting them.
+	PruneDryRun bool
+}
+
+// Build builds the site into the output directory.
+func (app *Application) Build(opts BuildOptions) error {
+	started := time.Now()
+	app.Reset()
+	app.Log("build start")
+	var err error
+	status := "published"
+	app.outputDir = app.Config.OutputDir
+	if opts.Drafts {
+		dir, err := app.Config.draftsOutputDir()
+		if err != nil {
+			return err
+		}
+		status = "published,draft"
+		app.outputDir = dir
+		app.Log("drafts mode: build into %v", app.outputDir)
+	}
+	if app.manifest, err = loadManifest(app, opts.Full); err != nil {
+		return err
+	}
+	app.sitemap = newSitemap()
+	if err := app.manifest.AddConfigFiles(app); err != nil {
+		return err
+	}
+	if opts.Full {
+		app.Log("full build: ignore the build manifest")
+	} else if !app.manifest.HasPrevious() {
+		app.Log("no build manifest found: build everything")
+	} else if app.manifest.ConfigChanged() {
+		app.Log("configuration files have been changed: convert all articles")
+	} else if app.manifest.TemplatesChanged() {
+		app.Log("theme templates have been changed")
+	}
+	now := time.Now()
+	held := Articles{}
+	err = app.loadArticles(status, func(art *Article) bool {
+		if opts.Future || !art.PostedAt.After(now) {
+			return true
+		}
+		held = append(held, art)
+		return false
+	})
+	if err != nil {
+		return err
+	}
+	if err := app.LoadPages(status); err != nil {
+		return err
+	}
+	sort.Sort(sort.Reverse(held))
+	for _, art := range held {
+		app.Log("held back until %v: %v", art.PostedAt.Format(articleTimeFormat), art.FilePath)
+	}
+	renderer := newRenderer()
+	sem := make(chan int, app.Config.NumThreads)
+	var wg sync.WaitGroup
+
+	// articles
+	{
+		errch := make(chan error)
+		quit := make(chan int)
+		var errs multiError
+		go func() {
+			for {
+				select {
+				case err := <-errch:
+					errs = append(errs, err)
+				case <-quit:
+					return
+				}
+			}
+		}()
+		for _, art := range app.Articles {
+			wg.Add(1)
+			go func(art *Article) {
+				defer func() {
+					wg.Done()
+					<-sem
+				}()
+				sem <- 1
+				buildArticle(app, renderer, art, errch)
+			}(art)
+		}
+		wg.Wait()
+		quit <- 1
+		close(errch)
+		if len(errs) != 0 {
+			return fmt.Errorf("failed to build %d articles:\n%w", len(errs), errs)
+		}
+		app.Log("%d articles(%d cached)", app.Stats.Get("Article"), app.Stats.Get("Cached"))
+	}
+
+	// pages
+	for _, art := range app.Pages {
+		if err := buildPage(app, renderer, art); err != nil {
+			return err
+		}
+	}
+	app.Log("%d pages", app.Stats.Get("Page"))
+
+	// aliases
+	if err := buildAliases(app); err != nil {
+		return err
+	}
+
+	// index
+	if err := buildList(app, renderer, "list1", "Index", app.Articles,
+		func() map[any]any {
+			return H("App", app)
+		},
+		func(vm *viewModel) {
+		}); err != nil {
+		return err
+	}
+	app.Log("%d index pages", app.Stats.Get("Index"))
+
+	//tag
+	for tag, arts := range app.Tags {
+		if err := buildList(app, renderer, "list2", "Tag", arts,
+			func() map[any]any {
+				return H("App", app, "Tag", tag)
+			},
+			func(vm *viewModel) {
+				vm.Tag = tag
+			}); err != nil {
+			return err
+		}
+	}
+	app.Log("%d tag pages", app.Stats.Get("Tag"))
+
+	//annual
+	for syear, arts := range app.Years {
+		year := parseIntMust(syear)
+
+		if err := buildList(app, renderer, "list2", "Annual", arts,
+			func() map[any]any {
+				return H("App", app, "Year", year)
+			},
+			func(vm *viewModel) {
+				vm.Year = year
+			}); err != nil {
+			return err
+		}
+	}
+	app.Log("%d annual archive pages", app.Stats.Get("Annual"))
+
+	//monthly
+	for smonth, arts := range app.Months {
+		year := parseIntMust(smonth[0:4])
+		month := parseIntMust(smonth[4:6])
+
+		if err := buildList(app, renderer, "list2", "Monthly", arts,
+			func() map[any]any {
+				return H("App", app, "Year", year, "Month", month)
+			},
+			func(vm *viewModel) {
+				vm.Year = year
+				vm.Month = month
+			}); err != nil {
+			return err
+		}
+	}
+	app.Log("%d monthly archive pages", app.Stats.Get("Monthly"))
+
+	// taxonomies
+	if err := buildTaxonomies(app, renderer); err != nil {
+		return err
+	}
+
+	// series
+	if err := buildSeries(app, renderer); err != nil {
+		return err
+	}
+
+	// authors
+	if err := buildAuthors(app, renderer); err != nil {
+		return err
+	}
+
+	// include
+	tpl, err := app.pathTemplate("Include")
+	if err != nil {
+		return err
+	}
+	if err := buildTemplate(app, renderer, tpl, "include", "Include"); err != nil {
+		return err
+	}
+	app.Log("%d include pages", app.Stats.Get("Include"))
+
+	// feeds
+	if err := buildFeeds(app, renderer); err != nil {
+		return err
+	}
+	app.Log("%d feeds", app.Stats.Get("Feed"))
+
+	// search index
+	if err := buildSearchIndex(app); err != nil {
+		return err
+	}
+
+	// extras
+	if err := copyExtras(app, renderer, app.Config.ExtraFiles,
+		filepath.Join(app.Config.ContentDir, "extras")); err != nil {
+		return err
+	}
+	if err := copyExtras(app, renderer, app.Config.ThemeConfig.ExtraFiles,
+		filepath.Join(app.Config.ThemeDir, app.Config.Theme, "extras")); err != nil {
+		return err
+	}
+	app.Log("%d extra files", app.Stats.Get("Extra"))
+
+	// sitemap
+	if err := buildSitemap(app); err != nil {
+		return err
+	}
+	if err := buildRobots(app); err != nil {
+		return err
+	}
+	app.Log("%d files unchanged", app.Stats.Get("Unchanged"))
+
+	if err := app.manifest.Save(); err != nil {
+		return err
+	}
+	if opts.Prune || opts.PruneDryRun {
+		if err := prune(app, opts.PruneDryRun); err != nil {
+			return err
+		}
+	}
+
+	app.Log("-----------------------------")
+	app.Log("build: OK(%v)", time.Since(started))
+	app.Log("-----------------------------")
+	return nil
+}
+
+func copyExtras(app *Application, renderer *renderer, extras []ExtraFile, sdir string) error {
+	done := make(map[string]int)
+	odir := app.outputDir
+	for _, f := range extras {
+		path := filepath.Join(sdir, f.Src)
+		app.Debug("copy extras start: %v", path)
+		matches, err := filepath.Glob(path)
+		if err != nil {
+			return fmt.Errorf("%v: %w", path, err)
+		}
+		for _, m := range matches {
+			app.Stats.Inc("Extra")
+			if _, ok := done[m]; !ok {
+				done[m] = 1
+			} else {
+				continue
+			}
+			dst := filepath.Join(odir, f.Dst, filepath.Base(m))
+			app.Debug("copy extras: %v -> %v", m, dst)
+			if f.Template && isFile(m) {
+				txt, err := renderer.RenderPage(app, m, newViewModel(app, "", nil))
+				if err != nil {
+					return fmt.Errorf("%v: %w", m, err)
+				}
+				if err := writeOutput(app, txt, dst); err != nil {
+					return fmt.Errorf("%v: %w", m, err)
+				}
+				if rel, err := filepath.Rel(odir, dst); err == nil {
+					app.sitemap.Add(app, rel, time.Time{})
+				}
+			} else {
+				if isDir(m) {
+					if err := app.manifest.CopyTree(m, dst); err != nil {
+						return fmt.Errorf("%v: %w", m, err)
+					}
+				} else {
+					if _, err := app.manifest.CopyFile(m, dst); err != nil {
+						return fmt.Errorf("%v: %w", m, err)
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,195 @@
+package silkylog
+
+import (
+	"fmt"
+	"html"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// LinkError is a broken internal link found by Check.
+type LinkError struct {
+	File   string
+	Line   int
+	Link   string
+	Reason string
+}
+
+// Error returns the position, the reason and the link.
+func (e *LinkError) Error() string {
+	return fmt.Sprintf("%v:%d: %v: %v", e.File, e.Line, e.Reason, e.Link)
+}
+
+var reLinkAttr = regexp.MustCompile(`(?is)<([a-z][a-z0-9]*)\b[^>]*?\s(href|src)\s*=\s*(?:"([^"]*)"|'([^']*)')`)
+
+var reAnchorAttr = regexp.MustCompile(`(?i)\s(?:id|name)\s*=\s*(?:"([^"]*)"|'([^']*)')`)
+
+type linkChecker struct {
+	app     *Application
+	anchors map[string]map[string]bool
+}
+
+// resolve returns the output file served for the url path like fileServer
+// does. It returns an empty string if no file is served.
+func (lc *linkChecker) resolve(upath string) string {
+	odir := lc.app.Config.OutputDir
+	file := filepath.Join(odir, filepath.FromSlash(upath))
+	if strings.HasSuffix(upath, "/") || isDir(file) {
+		if index := filepath.Join(file, "index.html"); isFile(index) {
+			return index
+		}
+		return ""
+	}
+	if isFile(file) {
+		return file
+	}
+	if lc.app.Config.TrimHTML && isFile(file+".html") {
+		return file + ".html"
+	}
+	return ""
+}
+
+func (lc *linkChecker) hasAnchor(file, anchor string) (bool, error) {
+	anchors, ok := lc.anchors[file]
+	if !ok {
+		bts, err := os.ReadFile(file)
+		if err != nil {
+			return false, err
+		}
+		anchors = map[string]bool{}
+		for _, m := range reAnchorAttr.FindAllStringSubmatch(string(bts), -1) {
+			anchors[html.UnescapeString(m[1]+m[2])] = true
+		}
+		lc.anchors[file] = anchors
+	}
+	return anchors[anchor], nil
+}
+
+// check returns broken internal links in the html file.
+func (lc *linkChecker) check(file string) ([]error, error) {
+	bts, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	text := string(bts)
+	rel, err := filepath.Rel(lc.app.Config.OutputDir, file)
+	if err != nil {
+		return nil, err
+	}
+	base := "/" + path.Dir(filepath.ToSlash(rel)) + "/"
+	siteURL, err := url.Parse(lc.app.Config.SiteUrl)
+	if err != nil {
+		return nil, err
+	}
+
+	errs := []error{}
+	for _, idx := range reLinkAttr.FindAllStringSubmatchIndex(text, -1) {
+		tag := strings.ToLower(text[idx[2]:idx[3]])
+		attr := strings.ToLower(text[idx[4]:idx[5]])
+		var link string
+		if idx[6] > -1 {
+			link = text[idx[6]:idx[7]]
+		} else {
+			link = text[idx[8]:idx[9]]
+		}
+		link = strings.TrimSpace(html.UnescapeString(link))
+		if tag == "base" || len(link) == 0 {
+			continue
+		}
+		u, err := url.Parse(link)
+		if err != nil {
+			continue
+		}
+		if len(u.Scheme) != 0 || len(u.Host) != 0 {
+			if u.Host != siteURL.Host || !strings.HasPrefix(u.Path, siteURL.Path) {
+				// external links
+				continue
+			}
+			u.Path = "/" + strings.TrimPrefix(u.Path, siteURL.Path)
+		}
+		lerr := &LinkError{
+			File: file,
+			Line: strings.Count(text[:idx[0]], "\n") + 1,
+			Link: link,
+		}
+		target := file
+		if len(u.Path) != 0 {
+			upath := u.Path
+			if !strings.HasPrefix(upath, "/") {
+				upath = base + upath
+			}
+			trailing := strings.HasSuffix(upath, "/")
+			upath = path.Clean(upath)
+			if trailing && upath != "/" {
+				upath += "/"
+			}
+			target = lc.resolve(upath)
+		}
+		switch {
+		case len(target) == 0 && attr == "src":
+			lerr.Reason = "missing asset"
+		case len(target) == 0 && tag == "link" && len(u.Fragment) == 0:
+			lerr.Reason = "missing asset"
+		case len(target) == 0:
+			lerr.Reason = "broken link"
+		case len(u.Fragment) != 0 && strings.HasSuffix(target, ".html"):
+			ok, err := lc.hasAnchor(target, u.Fragment)
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				lerr.Reason = "missing anchor"
+			}
+		}
+		if len(lerr.Reason) != 0 {
+			errs = append(errs, lerr)
+		}
+	}
+	return errs, nil
+}
+
+// Check checks internal links, anchors and assets referenced by html files in
+// the output directory.
+func (app *Application) Check() error {
+	app.Log("check start")
+	lc := &linkChecker{app: app, anchors: map[string]map[string]bool{}}
+	var errs multiError
+	files := 0
+	err := filepath.Walk(app.Config.OutputDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if strings.HasPrefix(info.Name(), ".") && path != app.Config.OutputDir {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if info.IsDir() || !strings.HasSuffix(path, ".html") {
+			return nil
+		}
+		files++
+		app.Debug("check: %v", path)
+		lerrs, err := lc.check(path)
+		if err != nil {
+			return fmt.Errorf("%v: %w", path, err)
+		}
+		errs = append(errs, lerrs...)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	app.Log("%d html files", files)
+	if len(errs) != 0 {
+		return fmt.Errorf("found %d broken links:\n%w", len(errs), errs)
+	}
+	app.Log("-----------------------------")
+	app.Log("check: OK")
+	app.Log("-----------------------------")
+	return nil
+}
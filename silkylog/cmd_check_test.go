@@ -0,0 +1,103 @@
+package silkylog
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func newTestLinkChecker(t *testing.T, files map[string]string) (*linkChecker, string) {
+	dir := t.TempDir()
+	for name, data := range files {
+		if err := writeFile(data, filepath.Join(dir, filepath.FromSlash(name))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	app := newTestApplication()
+	app.Config.OutputDir = dir
+	app.Config.SiteUrl = "http://example.com/blog/"
+	app.Config.TrimHTML = true
+	return &linkChecker{app: app, anchors: map[string]map[string]bool{}}, dir
+}
+
+func TestLinkCheckerResolve(t *testing.T) {
+	lc, dir := newTestLinkChecker(t, map[string]string{
+		"index.html":      "",
+		"about.html":      "",
+		"docs/index.html": "",
+		"docs/guide.html": "",
+		"css/main.css":    "",
+		"empty/.keep":     "",
+	})
+	cases := []struct {
+		path string
+		want string
+	}{
+		{"/", "index.html"},
+		{"/about", "about.html"},
+		{"/about.html", "about.html"},
+		{"/docs", "docs/index.html"},
+		{"/docs/", "docs/index.html"},
+		{"/docs/guide", "docs/guide.html"},
+		{"/css/main.css", "css/main.css"},
+		{"/css/main", ""},
+		{"/empty/", ""},
+		{"/missing", ""},
+		{"/about/", ""},
+	}
+	for _, c := range cases {
+		want := ""
+		if len(c.want) != 0 {
+			want = filepath.Join(dir, filepath.FromSlash(c.want))
+		}
+		if got := lc.resolve(c.path); got != want {
+			t.Errorf("resolve(%q): got %q, want %q", c.path, got, want)
+		}
+	}
+}
+
+func TestLinkCheckerCheck(t *testing.T) {
+	lc, dir := newTestLinkChecker(t, map[string]string{
+		"about.html": `<h2 id="team">Team</h2>`,
+		"docs/guide.html": `<h2 id='intro'>Intro</h2><a name="old">
+<a href="../about">about</a>
+<a href="../about#team">team</a>
+<a href="../about#nobody">nobody</a>
+<a href="#intro">intro</a>
+<a href="#old">old</a>
+<a href="guide?q=1#nope">nope</a>
+<a href="/nowhere">nowhere</a>
+<img src="/img/x.png">
+<link rel="stylesheet" href="/css/none.css">
+<a href="http://example.com/blog/about">absolute</a>
+<a href="http://example.com/blog/gone">gone</a>
+<a href="https://example.org/x">external</a>
+<a href="mailto:a@example.com">mail</a>
+<base href="/nowhere/">`,
+	})
+	errs, err := lc.check(filepath.Join(dir, "docs", "guide.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	type result struct {
+		line   int
+		link   string
+		reason string
+	}
+	got := []result{}
+	for _, err := range errs {
+		lerr := err.(*LinkError)
+		got = append(got, result{lerr.Line, lerr.Link, lerr.Reason})
+	}
+	want := []result{
+		{4, "../about#nobody", "missing anchor"},
+		{7, "guide?q=1#nope", "missing anchor"},
+		{8, "/nowhere", "broken link"},
+		{9, "/img/x.png", "missing asset"},
+		{10, "/css/none.css", "missing asset"},
+		{12, "http://example.com/blog/gone", "broken link"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,119 @@
+package silkylog
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+const watchDebounce = 300 * time.Millisecond
+
+func addWatchTree(watcher *fsnotify.Watcher, root string) error {
+	return filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if !info.IsDir() {
+			return nil
+		}
+		if path != root && strings.HasPrefix(info.Name(), ".") {
+			return filepath.SkipDir
+		}
+		return watcher.Add(path)
+	})
+}
+
+func isWatchTarget(app *Application, path string) bool {
+	basename := filepath.Base(path)
+	if strings.HasPrefix(basename, ".") || strings.HasSuffix(basename, "~") {
+		return false
+	}
+	if isSubPath(app.Config.OutputDir, path) {
+		return false
+	}
+	if len(app.Config.DraftsOutputDir) != 0 && isSubPath(app.Config.DraftsOutputDir, path) {
+		return false
+	}
+	if filepath.Dir(path) == filepath.Dir(app.configPath) {
+		return basename == filepath.Base(app.configPath)
+	}
+	return true
+}
+
+func rebuild(app *Application, opts BuildOptions) {
+	if err := app.LoadConfig(app.configPath); err != nil {
+		app.Log("build: NG\n%v", err)
+		return
+	}
+	if err := app.Build(opts); err != nil {
+		app.Log("build: NG\n%v", err)
+	}
+}
+
+// Watch builds the site and rebuilds it whenever sources, the theme or the
+// config file are changed. Watch blocks until the watcher is closed.
+func (app *Application) Watch(opts BuildOptions) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = watcher.Close()
+	}()
+	roots := func() []string {
+		return []string{filepath.Dir(app.configPath), app.Config.ContentDir,
+			filepath.Join(app.Config.ThemeDir, app.Config.Theme)}
+	}
+	if err := watcher.Add(roots()[0]); err != nil {
+		return err
+	}
+	for _, root := range roots()[1:] {
+		if err := addWatchTree(watcher, root); err != nil {
+			return err
+		}
+	}
+
+	if err := app.Build(opts); err != nil {
+		app.Log("build: NG\n%v", err)
+	}
+	app.Log("watching %v", strings.Join(roots(), ", "))
+
+	timer := time.NewTimer(watchDebounce)
+	timer.Stop()
+	for {
+		select {
+		case ev, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			if !isWatchTarget(app, ev.Name) {
+				continue
+			}
+			app.Debug("watch: %v", ev)
+			if ev.Op&fsnotify.Create == fsnotify.Create && isDir(ev.Name) {
+				if err := addWatchTree(watcher, ev.Name); err != nil {
+					app.Log("watch: %v", err)
+				}
+			}
+			timer.Reset(watchDebounce)
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			app.Log("watch: %v", err)
+		case <-timer.C:
+			app.Log("changes detected, rebuilding")
+			rebuild(app, opts)
+			// the theme may have been changed by config.lua
+			if err := addWatchTree(watcher, roots()[2]); err != nil {
+				app.Log("watch: %v", err)
+			}
+		}
+	}
+}
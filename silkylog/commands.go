@@ -1,4 +1,4 @@
-package main
+package silkylog
 
 import (
 	"bytes"
@@ -7,12 +7,21 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 )
 
-func clean(app *application) error {
+// Clean removes the files listed in the clean config from the output
+// directory, or from drafts_output_dir if drafts is true.
+func (app *Application) Clean(drafts bool) error {
 	app.Log("clean start")
 	outputdir := app.Config.OutputDir
+	if drafts {
+		var err error
+		if outputdir, err = app.Config.draftsOutputDir(); err != nil {
+			return err
+		}
+	}
 	for _, target := range app.Config.Clean {
 		path := filepath.Join(outputdir, target)
 		app.Log("remove: %v", path)
@@ -26,7 +35,8 @@ func clean(app *application) error {
 	return nil
 }
 
-func newsite(app *application, path string) error {
+// NewSite creates a new site under the path.
+func (app *Application) NewSite(path string) error {
 	if len(path) == 0 {
 		return errors.New("empty path")
 	}
@@ -57,6 +67,10 @@ func newsite(app *application, path string) error {
 			return err
 		}
 	}
+	// remove the library package
+	if err := os.RemoveAll(filepath.Join(silkylogpath, "silkylog")); err != nil {
+		return err
+	}
 	// remove files
 	for _, rfile := range []string{".gitignore", "LICENSE", "README.rst"} {
 		rpath := filepath.Join(silkylogpath, rfile)
@@ -89,7 +103,9 @@ func newsite(app *application, path string) error {
 	return nil
 }
 
-func newarticle(app *application) error {
+// NewArticle creates a new article interactively and opens it with the
+// editor.
+func (app *Application) NewArticle() error {
 	const timeformat = "2006-01-02 15:04:05"
 	var dates string
 	var date time.Time
@@ -121,35 +137,39 @@ func newarticle(app *application) error {
 	return nil
 }
 
-func preview(app *application, port int, path string) error {
+// Preview serves the output directory and the article at the path on
+// /preview.
+func (app *Application) Preview(port int, path string, livereload bool) error {
 	if len(path) == 0 {
 		return errors.New("empty path")
 	}
 	addr := fmt.Sprintf(":%v", port)
-	err := app.CompileTemplates()
-	if err != nil {
-		return err
+	mux := http.NewServeMux()
+	var lr *liveReloader
+	if livereload {
+		lr = newLiveReloader()
+		if err := lr.Watch(app, app.Config.OutputDir, path); err != nil {
+			return err
+		}
+		mux.Handle(liveReloadPath, lr)
 	}
-	fileserver := fileServer(app)
-	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+	fileserver := fileServer(app, app.Config.OutputDir, lr)
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
 		urlpath := r.URL.Path
 		if urlpath == "/preview" {
 			w.Header().Set("Content-Type", "text/html; charset=UTF-8")
-			art, err := loadArticle(app, path)
+			art, err := app.LoadArticle(path)
 			if err != nil {
 				_, _ = w.Write(([]byte)(err.Error()))
 				return
 			}
-			renderer := newRenderer()
-			if err := app.ConvertArticleText(art); err != nil {
+			html, err := app.Render(art)
+			if err != nil {
 				_, _ = w.Write(([]byte)(err.Error()))
 				return
 			}
-			title := app.Title("Article", H("App", app, "Article", art))
-			html, err2 := renderer.RenderPage(app, "article", newViewModel(app, title, art))
-			if err2 != nil {
-				_, _ = w.Write(([]byte)(err2.Error()))
-				return
+			if lr != nil {
+				html = string(injectLiveReload(([]byte)(html)))
 			}
 			_, _ = w.Write(([]byte)(html))
 		} else {
@@ -157,30 +177,51 @@ func preview(app *application, port int, path string) error {
 			return
 		}
 	})
-	return http.ListenAndServe(addr, nil)
+	return http.ListenAndServe(addr, mux)
 }
 
-func fileServer(app *application) func(w http.ResponseWriter, r *http.Request) {
-	fileserver := http.StripPrefix("/", http.FileServer(http.Dir(app.Config.OutputDir)))
+func fileServer(app *Application, dir string, lr *liveReloader) func(w http.ResponseWriter, r *http.Request) {
+	fileserver := http.StripPrefix("/", http.FileServer(http.Dir(dir)))
 	return func(w http.ResponseWriter, r *http.Request) {
 		w2 := newResponseWriter(w)
 		fileserver.ServeHTTP(w2, r)
 		if w2.Status == 404 && app.Config.TrimHTML {
 			w.Header().Set("Content-Type", "text/html; charset=UTF-8")
 			r.URL.Path = r.URL.Path + ".html"
-			fileserver.ServeHTTP(w, r)
-		} else {
-			w.WriteHeader(w2.Status)
-			w.Write(w2.Buf.Bytes())
+			w2 = newResponseWriter(w)
+			fileserver.ServeHTTP(w2, r)
+		}
+		body := w2.Buf.Bytes()
+		if lr != nil && w2.Status == 200 && strings.HasPrefix(w.Header().Get("Content-Type"), "text/html") {
+			body = injectLiveReload(body)
+			w.Header().Del("Content-Length")
 		}
+		w.WriteHeader(w2.Status)
+		_, _ = w.Write(body)
 	}
 }
 
-func serve(app *application, port int) error {
+// Serve serves the output directory, or drafts_output_dir if drafts is true.
+func (app *Application) Serve(port int, livereload, drafts bool) error {
+	dir := app.Config.OutputDir
+	if drafts {
+		var err error
+		if dir, err = app.Config.draftsOutputDir(); err != nil {
+			return err
+		}
+	}
 	addr := fmt.Sprintf(":%v", port)
-	http.HandleFunc("/", fileServer(app))
-	http.ListenAndServe(addr, nil)
-	return nil
+	mux := http.NewServeMux()
+	var lr *liveReloader
+	if livereload {
+		lr = newLiveReloader()
+		if err := lr.Watch(app, dir); err != nil {
+			return err
+		}
+		mux.Handle(liveReloadPath, lr)
+	}
+	mux.HandleFunc("/", fileServer(app, dir, lr))
+	return http.ListenAndServe(addr, mux)
 }
 
 type responseWriter struct {
@@ -0,0 +1,176 @@
+package silkylog
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/yuin/gluamapper"
+	lua "github.com/yuin/gopher-lua"
+)
+
+// Config is a site configuration loaded from config.lua.
+type Config struct {
+	Debug       bool
+	SiteUrl     string
+	Editor      []string
+	NumThreads  int
+	Timezone    string
+	Theme       string
+	Pagination1 int
+	Pagination2 int
+	TrimHTML    bool
+
+	SummaryWords      int
+	WordsPerMinute    int
+	CJKCharsPerMinute int
+	Feeds             FeedsConfig
+	Sitemap           SitemapConfig
+	TOC               TOCConfig
+	Related           RelatedConfig
+	Search            SearchConfig
+	Prune             PruneConfig
+	Redirects         RedirectsConfig
+
+	Params  map[string]interface{}
+	Authors []AuthorConfig
+
+	TopUrlPath string
+
+	ArticleUrlPath string
+	ArticleTitle   string
+
+	PageUrlPath string
+	PageTitle   string
+
+	IndexUrlPath string
+	IndexTitle   string
+
+	TagUrlPath     string
+	TagTitle       string
+	TagFeedUrlPath string
+
+	AnnualUrlPath     string
+	AnnualTitle       string
+	AnnualFeedUrlPath string
+
+	MonthlyUrlPath string
+	MonthlyTitle   string
+
+	SeriesUrlPath string
+	SeriesTitle   string
+
+	AuthorUrlPath string
+	AuthorTitle   string
+
+	Taxonomies []TaxonomyConfig
+
+	IncludeUrlPath string
+	FeedUrlPath    string
+	FileUrlPath    string
+
+	ContentDir      string
+	ThemeDir        string
+	OutputDir       string
+	DraftsOutputDir string
+	ExtraFiles      []ExtraFile
+	Clean           []string
+
+	MarkupProcessors map[string]interface{}
+
+	ThemeConfig *Config
+
+	location *time.Location
+}
+
+// ExtraFile is a file copied into the output directory.
+type ExtraFile struct {
+	Src      string `mapstructure:"src"`
+	Dst      string `mapstructure:"dst"`
+	Template bool   `mapstructure:"template"`
+}
+
+// Param returns the string param of the name.
+func (cfg *Config) Param(name string) string {
+	s, _ := cfg.Params[name].(string)
+	return s
+}
+
+// draftsOutputDir returns drafts_output_dir. Drafts must never be written
+// into output_dir.
+func (cfg *Config) draftsOutputDir() (string, error) {
+	if len(cfg.DraftsOutputDir) == 0 {
+		return "", errors.New("drafts_output_dir is not configured")
+	}
+	if filepath.Clean(cfg.DraftsOutputDir) == filepath.Clean(cfg.OutputDir) {
+		return "", errors.New("drafts_output_dir must differ from output_dir")
+	}
+	return cfg.DraftsOutputDir, nil
+}
+
+// Location returns the time zone of the site parsed from the timezone setting.
+func (cfg *Config) Location() (*time.Location, error) {
+	if cfg.location == nil {
+		re := regexp.MustCompile(`([^\s]+) ([\+\-])(\d+):(\d+)`)
+		groups := re.FindStringSubmatch(cfg.Timezone)
+		if len(groups) == 0 {
+			loc, err := time.LoadLocation(cfg.Timezone)
+			if err != nil {
+				return nil, errors.New("invalid timezone: " + cfg.Timezone)
+			}
+			cfg.location = loc
+		} else {
+			hour, _ := strconv.ParseInt(groups[3], 10, 32)
+			min, _ := strconv.ParseInt(groups[4], 10, 32)
+			sec := hour*60*60 + min*60
+			if groups[2] == "-" {
+				sec = sec * -1
+			}
+			cfg.location = time.FixedZone(groups[1], int(sec))
+		}
+	}
+	return cfg.location, nil
+}
+
+func (app *Application) loadConfig(L *lua.LState) (*Config, error) {
+	L.PreloadModule("silkylog", app.LuaModuleLoader)
+	cfg := &Config{}
+	L.SetGlobal("config", L.NewFunction(func(L *lua.LState) int {
+		tbl := L.CheckTable(1)
+		if err := gluamapper.Map(tbl, cfg); err != nil {
+			L.RaiseError("%v", err.Error())
+		}
+		L.SetGlobal("CONFIG", tbl)
+		return 0
+	}))
+	if err := L.DoFile(app.configPath); err != nil {
+		return nil, fmt.Errorf("failed to load %v:\n\n%v", app.configPath, err.Error())
+	}
+	themecfg := &Config{}
+	L.SetGlobal("config", L.NewFunction(func(L *lua.LState) int {
+		tbl := L.CheckTable(1)
+		if err := gluamapper.Map(tbl, themecfg); err != nil {
+			L.RaiseError("%v", err.Error())
+		}
+		L.SetGlobal("THEME_CONFIG", tbl)
+		return 0
+	}))
+	if err := L.DoFile(filepath.Join(cfg.ThemeDir, cfg.Theme, "theme.lua")); err != nil {
+		return nil, fmt.Errorf("failed to load theme.lua:\n\n%v", err.Error())
+	}
+	cfg.ThemeConfig = themecfg
+	if _, err := cfg.Location(); err != nil {
+		return nil, err
+	}
+	if err := cfg.checkAuthors(); err != nil {
+		return nil, err
+	}
+	if err := cfg.checkPages(); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
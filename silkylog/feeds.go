@@ -0,0 +1,403 @@
+package silkylog
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+const defaultFeedSize = 10
+
+// FeedsConfig configures builtin Atom and JSON feeds.
+type FeedsConfig struct {
+	// Atom is a name of the Atom feed. An empty name disables the feed.
+	Atom string
+	// JSON is a name of the JSON feed. An empty name disables the feed.
+	JSON string
+	// Size is a number of articles in feeds.
+	Size int
+	// FullContent includes whole articles instead of summaries in feeds.
+	FullContent bool
+
+	Title       string
+	Description string
+	Author      FeedAuthor
+}
+
+// FeedAuthor is an author of feeds.
+type FeedAuthor struct {
+	Name   string
+	Email  string
+	URL    string
+	Avatar string
+}
+
+// FeedArticles returns the latest articles for feeds.
+func (app *Application) FeedArticles() Articles {
+	return app.Articles.SubList(0, app.feedSize())
+}
+
+func (app *Application) feedSize() int {
+	if app.Config.Feeds.Size <= 0 {
+		return defaultFeedSize
+	}
+	return app.Config.Feeds.Size
+}
+
+// TagFeedUrl returns an URL of the feed for the tag. It returns an empty
+// string if tag_feed_url_path is not configured.
+func (app *Application) TagFeedUrl(tag, name string) (string, error) {
+	if len(app.Config.TagFeedUrlPath) == 0 {
+		return "", nil
+	}
+	return app.Url("TagFeed", H("Tag", tag, "Name", name))
+}
+
+// AnnualFeedUrl returns an URL of the feed for the year. It returns an empty
+// string if annual_feed_url_path is not configured.
+func (app *Application) AnnualFeedUrl(year int, name string) (string, error) {
+	if len(app.Config.AnnualFeedUrlPath) == 0 {
+		return "", nil
+	}
+	return app.Url("AnnualFeed", H("Year", year, "Name", name))
+}
+
+// FeedChannel is a list of articles published as a feed.
+type FeedChannel struct {
+	Title       string
+	Description string
+	Link        string
+	Tag         string
+	Year        int
+	Articles    Articles
+}
+
+// Updated returns the latest update time of the articles in the channel.
+func (ch *FeedChannel) Updated() time.Time {
+	updated := time.Time{}
+	for _, art := range ch.Articles {
+		if art.UpdatedAt.After(updated) {
+			updated = art.UpdatedAt
+		}
+	}
+	if updated.IsZero() {
+		return time.Now()
+	}
+	return updated
+}
+
+func (app *Application) siteFeedChannel() *FeedChannel {
+	cfg := app.Config.Feeds
+	ch := &FeedChannel{
+		Title:       cfg.Title,
+		Description: cfg.Description,
+		Link:        app.Config.SiteUrl,
+		Articles:    app.FeedArticles(),
+	}
+	if len(ch.Title) == 0 {
+		ch.Title = app.Config.Param("SiteName")
+	}
+	if len(ch.Description) == 0 {
+		ch.Description = app.Config.Param("SiteDescription")
+	}
+	return ch
+}
+
+func (app *Application) tagFeedChannel(tag string, arts []*Article) (*FeedChannel, error) {
+	link, err := app.FullURL("Tag", H("Tag", tag, "Page", 0))
+	if err != nil {
+		return nil, err
+	}
+	ch := app.siteFeedChannel()
+	ch.Title = ch.Title + " :: tag :: " + tag
+	ch.Link = link
+	ch.Tag = tag
+	ch.Articles = Articles(arts).SubList(0, app.feedSize())
+	return ch, nil
+}
+
+func (app *Application) annualFeedChannel(year int, arts []*Article) (*FeedChannel, error) {
+	link, err := app.FullURL("Annual", H("Year", year, "Page", 0))
+	if err != nil {
+		return nil, err
+	}
+	ch := app.siteFeedChannel()
+	ch.Title = fmt.Sprintf("%v :: annual archive :: %d", ch.Title, year)
+	ch.Link = link
+	ch.Year = year
+	ch.Articles = Articles(arts).SubList(0, app.feedSize())
+	return ch, nil
+}
+
+func (app *Application) feedAuthor() FeedAuthor {
+	author := app.Config.Feeds.Author
+	if len(author.Name) == 0 {
+		author.Name = app.Config.Param("Author")
+	}
+	return author
+}
+
+type atomFeed struct {
+	XMLName  xml.Name     `xml:"http://www.w3.org/2005/Atom feed"`
+	ID       string       `xml:"id"`
+	Title    string       `xml:"title"`
+	Subtitle string       `xml:"subtitle,omitempty"`
+	Updated  string       `xml:"updated"`
+	Links    []atomLink   `xml:"link"`
+	Author   *atomPerson  `xml:"author,omitempty"`
+	Rights   string       `xml:"rights,omitempty"`
+	Entries  []*atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+}
+
+type atomPerson struct {
+	Name  string `xml:"name"`
+	Email string `xml:"email,omitempty"`
+	URI   string `xml:"uri,omitempty"`
+}
+
+type atomText struct {
+	Type string `xml:"type,attr"`
+	Body string `xml:",chardata"`
+}
+
+type atomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+type atomEntry struct {
+	ID         string         `xml:"id"`
+	Title      string         `xml:"title"`
+	Links      []atomLink     `xml:"link"`
+	Published  string         `xml:"published"`
+	Updated    string         `xml:"updated"`
+	Authors    []*atomPerson  `xml:"author"`
+	Categories []atomCategory `xml:"category"`
+	Summary    *atomText      `xml:"summary,omitempty"`
+	Content    *atomText      `xml:"content,omitempty"`
+}
+
+func (app *Application) atomFeed(ch *FeedChannel, selfURL string) (string, error) {
+	author := app.feedAuthor()
+	feed := &atomFeed{
+		ID:       ch.Link,
+		Title:    ch.Title,
+		Subtitle: ch.Description,
+		Updated:  ch.Updated().Format(time.RFC3339),
+		Links: []atomLink{
+			{Href: selfURL, Rel: "self", Type: "application/atom+xml"},
+			{Href: ch.Link, Rel: "alternate", Type: "text/html"},
+		},
+		Author: &atomPerson{Name: author.Name, Email: author.Email, URI: author.URL},
+	}
+	if len(author.Name) == 0 {
+		feed.Author.Name = ch.Title
+	}
+	for _, art := range ch.Articles {
+		entry := &atomEntry{
+			ID:        art.PermlinkUrl,
+			Title:     art.Title,
+			Links:     []atomLink{{Href: art.PermlinkUrl, Rel: "alternate", Type: "text/html"}},
+			Published: art.PostedAt.Format(time.RFC3339),
+			Updated:   art.UpdatedAt.Format(time.RFC3339),
+			Summary:   &atomText{Type: "html", Body: art.SummaryHTML},
+		}
+		for _, author := range art.Authors {
+			entry.Authors = append(entry.Authors, &atomPerson{Name: author.Name, Email: author.Email, URI: author.URL})
+		}
+		for _, tag := range art.Tags {
+			entry.Categories = append(entry.Categories, atomCategory{Term: tag})
+		}
+		if app.Config.Feeds.FullContent {
+			entry.Content = &atomText{Type: "html", Body: art.BodyHTML}
+		}
+		feed.Entries = append(feed.Entries, entry)
+	}
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	enc := xml.NewEncoder(&buf)
+	enc.Indent("", "  ")
+	if err := enc.Encode(feed); err != nil {
+		return "", err
+	}
+	buf.WriteString("\n")
+	return buf.String(), nil
+}
+
+const jsonFeedVersion = "https://jsonfeed.org/version/1.1"
+
+type jsonFeed struct {
+	Version     string          `json:"version"`
+	Title       string          `json:"title"`
+	HomePageURL string          `json:"home_page_url,omitempty"`
+	FeedURL     string          `json:"feed_url,omitempty"`
+	Description string          `json:"description,omitempty"`
+	Authors     []*jsonAuthor   `json:"authors,omitempty"`
+	Items       []*jsonFeedItem `json:"items"`
+}
+
+type jsonAuthor struct {
+	Name   string `json:"name,omitempty"`
+	URL    string `json:"url,omitempty"`
+	Avatar string `json:"avatar,omitempty"`
+}
+
+type jsonFeedItem struct {
+	ID            string        `json:"id"`
+	URL           string        `json:"url"`
+	Title         string        `json:"title"`
+	ContentHTML   string        `json:"content_html"`
+	Summary       string        `json:"summary,omitempty"`
+	DatePublished string        `json:"date_published"`
+	DateModified  string        `json:"date_modified"`
+	Authors       []*jsonAuthor `json:"authors,omitempty"`
+	Tags          []string      `json:"tags,omitempty"`
+}
+
+func (app *Application) jsonFeed(ch *FeedChannel, selfURL string) (string, error) {
+	feed := &jsonFeed{
+		Version:     jsonFeedVersion,
+		Title:       ch.Title,
+		HomePageURL: ch.Link,
+		FeedURL:     selfURL,
+		Description: ch.Description,
+		Items:       []*jsonFeedItem{},
+	}
+	if author := app.feedAuthor(); len(author.Name) != 0 {
+		feed.Authors = []*jsonAuthor{{Name: author.Name, URL: author.URL, Avatar: author.Avatar}}
+	}
+	for _, art := range ch.Articles {
+		item := &jsonFeedItem{
+			ID:            art.PermlinkUrl,
+			URL:           art.PermlinkUrl,
+			Title:         art.Title,
+			ContentHTML:   art.SummaryHTML,
+			Summary:       art.Summary,
+			DatePublished: art.PostedAt.Format(time.RFC3339),
+			DateModified:  art.UpdatedAt.Format(time.RFC3339),
+			Tags:          art.Tags,
+		}
+		for _, author := range art.Authors {
+			item.Authors = append(item.Authors, &jsonAuthor{Name: author.Name, URL: author.URL, Avatar: author.Avatar})
+		}
+		if app.Config.Feeds.FullContent {
+			item.ContentHTML = art.BodyHTML
+		}
+		feed.Items = append(feed.Items, item)
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(feed); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// writeFeeds writes the theme feed templates and enabled builtin feeds of the
+// channel. pathf returns an output path for a feed name.
+func (app *Application) writeFeeds(renderer *renderer, ch *FeedChannel, pathf func(name string) (string, error)) error {
+	lst, err := os.ReadDir(filepath.Join(app.Config.ThemeDir, app.Config.Theme, "feeds"))
+	if err != nil {
+		return err
+	}
+	vm := newViewModel(app, ch.Title, nil)
+	vm.Feed = ch
+	vm.Tag = ch.Tag
+	vm.Year = ch.Year
+	for _, item := range lst {
+		path, err := pathf(item.Name())
+		if err != nil {
+			return err
+		}
+		app.Stats.Inc("Feed")
+		app.Debug("feed: %v", path)
+		txt, err := renderer.RenderType(app, item.Name(), "feeds", vm)
+		if err != nil {
+			return fmt.Errorf("feeds/%v : %w", item.Name(), err)
+		}
+		if err := writeOutput(app, txt, filepath.Join(app.outputDir, path)); err != nil {
+			return fmt.Errorf("%v: %w", path, err)
+		}
+	}
+
+	cfg := app.Config.Feeds
+	for _, feed := range []struct {
+		name string
+		gen  func(*FeedChannel, string) (string, error)
+	}{
+		{cfg.Atom, app.atomFeed},
+		{cfg.JSON, app.jsonFeed},
+	} {
+		if len(feed.name) == 0 {
+			continue
+		}
+		path, err := pathf(feed.name)
+		if err != nil {
+			return err
+		}
+		app.Stats.Inc("Feed")
+		app.Debug("feed: %v", path)
+		data, err := feed.gen(ch, app.Config.SiteUrl+urlEncode(path))
+		if err != nil {
+			return fmt.Errorf("%v: %w", path, err)
+		}
+		if err := writeOutput(app, data, filepath.Join(app.outputDir, path)); err != nil {
+			return fmt.Errorf("%v: %w", path, err)
+		}
+	}
+	return nil
+}
+
+func buildFeeds(app *Application, renderer *renderer) error {
+	if err := app.writeFeeds(renderer, app.siteFeedChannel(), func(name string) (string, error) {
+		return app.Path("Feed", H("Name", name))
+	}); err != nil {
+		return err
+	}
+
+	if len(app.Config.TagFeedUrlPath) != 0 {
+		for tag, arts := range app.Tags {
+			tag := tag
+			ch, err := app.tagFeedChannel(tag, arts)
+			if err != nil {
+				return err
+			}
+			if err := app.writeFeeds(renderer, ch, func(name string) (string, error) {
+				return app.Path("TagFeed", H("Tag", tag, "Name", name))
+			}); err != nil {
+				return err
+			}
+		}
+	}
+
+	if len(app.Config.AnnualFeedUrlPath) != 0 {
+		for syear, arts := range app.Years {
+			year, err := strconv.Atoi(syear)
+			if err != nil {
+				return err
+			}
+			ch, err := app.annualFeedChannel(year, arts)
+			if err != nil {
+				return err
+			}
+			if err := app.writeFeeds(renderer, ch, func(name string) (string, error) {
+				return app.Path("AnnualFeed", H("Year", year, "Name", name))
+			}); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,185 @@
+package silkylog
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	yamlFrontMatterDelimiter = "---"
+	tomlFrontMatterDelimiter = "+++"
+)
+
+// frontMatterAliases maps keys used by other static site generators like
+// Hugo and Jekyll to silkylog header names.
+var frontMatterAliases = map[string]string{
+	"date":    "posted_at",
+	"lastmod": "updated_at",
+	"updated": "updated_at",
+}
+
+// parseFrontMatter parses a YAML or TOML front matter block at the beginning
+// of the text and returns the rest of the text.
+func parseFrontMatter(app *Application, art *Article, delimiter, text string) (string, error) {
+	lines := strings.SplitAfter(text, "\n")
+	end := -1
+	for i := 1; i < len(lines); i++ {
+		if strings.TrimSpace(lines[i]) == delimiter {
+			end = i
+			break
+		}
+	}
+	if end < 0 {
+		return "", errors.New("front matter is not closed with " + delimiter)
+	}
+	source := strings.Join(lines[1:end], "")
+	data := map[string]interface{}{}
+	var err error
+	if delimiter == yamlFrontMatterDelimiter {
+		data, err = yamlFrontMatter(source)
+	} else {
+		_, err = toml.Decode(source, &data)
+	}
+	if err != nil {
+		return "", fmt.Errorf("invalid front matter: %w", err)
+	}
+
+	keys := make([]string, 0, len(data))
+	for key := range data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		name := key
+		if alias, ok := frontMatterAliases[key]; ok {
+			name = alias
+		}
+		v := data[key]
+		art.addHeader(name, v)
+		switch name {
+		case "draft", "published":
+			b, ok := v.(bool)
+			if !ok {
+				return "", errors.New("invalid " + key + ": must be a boolean")
+			}
+			if _, ok := data["status"]; ok {
+				continue
+			}
+			if b == (name == "draft") {
+				art.Status = "draft"
+			} else {
+				art.Status = "published"
+			}
+			continue
+		}
+		if name == "author" {
+			art.Params[name] = art.headerTerms(name)
+			continue
+		}
+		if !articleHeaders[name] {
+			art.Params[key] = v
+			continue
+		}
+		value, err := frontMatterValue(v)
+		if err != nil {
+			return "", fmt.Errorf("invalid %v: %w", key, err)
+		}
+		if err := setArticleHeader(app, art, name, value); err != nil {
+			return "", err
+		}
+	}
+	return strings.Join(lines[end+1:], ""), nil
+}
+
+// yamlFrontMatter decodes YAML front matter. Scalars are kept as written
+// because YAML timestamps without offsets must be in the site timezone.
+func yamlFrontMatter(source string) (map[string]interface{}, error) {
+	data := map[string]interface{}{}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(([]byte)(source), &doc); err != nil {
+		return nil, err
+	}
+	if len(doc.Content) == 0 {
+		return data, nil
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, errors.New("front matter must be a mapping")
+	}
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		v, err := yamlNodeValue(root.Content[i+1])
+		if err != nil {
+			return nil, err
+		}
+		data[root.Content[i].Value] = v
+	}
+	return data, nil
+}
+
+func yamlNodeValue(node *yaml.Node) (interface{}, error) {
+	switch node.Kind {
+	case yaml.AliasNode:
+		return yamlNodeValue(node.Alias)
+	case yaml.SequenceNode:
+		values := make([]interface{}, 0, len(node.Content))
+		for _, child := range node.Content {
+			v, err := yamlNodeValue(child)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, v)
+		}
+		return values, nil
+	case yaml.MappingNode:
+		values := make(map[string]interface{}, len(node.Content)/2)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			v, err := yamlNodeValue(node.Content[i+1])
+			if err != nil {
+				return nil, err
+			}
+			values[node.Content[i].Value] = v
+		}
+		return values, nil
+	}
+	if node.Tag == "!!bool" {
+		var b bool
+		if err := node.Decode(&b); err != nil {
+			return nil, err
+		}
+		return b, nil
+	}
+	return node.Value, nil
+}
+
+func frontMatterValue(v interface{}) (string, error) {
+	switch val := v.(type) {
+	case string:
+		return val, nil
+	case time.Time:
+		// TOML local date-times have no offsets, they are in the site timezone.
+		if name := val.Location().String(); name == "datetime-local" || name == "date-local" {
+			return val.Format(articleTimeFormat), nil
+		}
+		return val.Format(time.RFC3339), nil
+	case []interface{}:
+		values := make([]string, 0, len(val))
+		for _, elem := range val {
+			s, err := frontMatterValue(elem)
+			if err != nil {
+				return "", err
+			}
+			values = append(values, s)
+		}
+		return strings.Join(values, ","), nil
+	case map[string]interface{}:
+		return "", errors.New("tables are not supported")
+	default:
+		return fmt.Sprint(val), nil
+	}
+}
@@ -0,0 +1,146 @@
+package silkylog
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestApplication() *Application {
+	app := New()
+	app.Config = &Config{Timezone: "JST +09:00"}
+	return app
+}
+
+func TestParseFrontMatter(t *testing.T) {
+	jst := time.FixedZone("JST", 9*60*60)
+	cases := []struct {
+		name   string
+		text   string
+		rest   string
+		err    string
+		verify func(*testing.T, *Article)
+	}{
+		{
+			name: "yaml",
+			text: "---\ntitle: \"Hello: world\"\ntags: [go, lua]\ndate: 2023-03-01 10:00:00\n---\nbody\n",
+			rest: "body\n",
+			verify: func(t *testing.T, art *Article) {
+				if art.Title != "Hello: world" {
+					t.Errorf("title: %q", art.Title)
+				}
+				if !reflect.DeepEqual(art.Tags, []string{"go", "lua"}) {
+					t.Errorf("tags: %v", art.Tags)
+				}
+				if want := time.Date(2023, 3, 1, 10, 0, 0, 0, jst); !art.PostedAt.Equal(want) {
+					t.Errorf("posted_at: %v", art.PostedAt)
+				}
+			},
+		},
+		{
+			name: "toml",
+			text: "+++\ntitle = \"TOML\"\ndraft = true\nlastmod = 2023-03-02T10:00:00Z\n+++\nbody",
+			rest: "body",
+			verify: func(t *testing.T, art *Article) {
+				if art.Title != "TOML" || art.Status != "draft" {
+					t.Errorf("title, status: %q, %q", art.Title, art.Status)
+				}
+				if want := time.Date(2023, 3, 2, 10, 0, 0, 0, time.UTC); !art.UpdatedAt.Equal(want) {
+					t.Errorf("updated_at: %v", art.UpdatedAt)
+				}
+			},
+		},
+		{
+			name: "empty",
+			text: "---\n---\nbody",
+			rest: "body",
+			verify: func(t *testing.T, art *Article) {
+				if len(art.Title) != 0 || len(art.Params) != 0 {
+					t.Errorf("unexpected headers: %+v", art)
+				}
+			},
+		},
+		{
+			name: "status wins over draft",
+			text: "---\nstatus: published\ndraft: true\n---\n",
+			verify: func(t *testing.T, art *Article) {
+				if art.Status != "published" {
+					t.Errorf("status: %q", art.Status)
+				}
+			},
+		},
+		{
+			name: "params",
+			text: "---\ncover_image: a.png\n---\n",
+			verify: func(t *testing.T, art *Article) {
+				if art.Params["cover_image"] != "a.png" {
+					t.Errorf("params: %v", art.Params)
+				}
+			},
+		},
+		{
+			name: "raw headers",
+			text: "---\nseries: Go tutorial\ncategories: [Go, \" Notes \"]\n---\n",
+			verify: func(t *testing.T, art *Article) {
+				if got := art.headerTerms("series"); !reflect.DeepEqual(got, []string{"Go tutorial"}) {
+					t.Errorf("series: %v", got)
+				}
+				if got := art.headerTerms("categories"); !reflect.DeepEqual(got, []string{"Go", "Notes"}) {
+					t.Errorf("categories: %v", got)
+				}
+			},
+		},
+		{
+			name: "author",
+			text: "---\nauthor: [Your name, Guest Writer]\n---\n",
+			verify: func(t *testing.T, art *Article) {
+				want := []string{"Your name", "Guest Writer"}
+				if got := art.Params["author"]; !reflect.DeepEqual(got, want) {
+					t.Errorf("params: %v", got)
+				}
+			},
+		},
+		{name: "not closed", text: "---\ntitle: x\n", err: "not closed"},
+		{name: "not a mapping", text: "---\n- a\n---\n", err: "must be a mapping"},
+		{name: "invalid draft", text: "---\ndraft: yes please\n---\n", err: "must be a boolean"},
+		{name: "table", text: "+++\n[title]\nx = 1\n+++\n", err: "tables are not supported"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			art := &Article{Tags: []string{}, Aliases: []string{}, Params: map[string]interface{}{}}
+			delimiter := strings.SplitN(c.text, "\n", 2)[0]
+			rest, err := parseFrontMatter(newTestApplication(), art, delimiter, c.text)
+			if len(c.err) != 0 {
+				if err == nil || !strings.Contains(err.Error(), c.err) {
+					t.Fatalf("expected an error %q, but got %v", c.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if rest != c.rest {
+				t.Errorf("rest: %q", rest)
+			}
+			c.verify(t, art)
+		})
+	}
+}
+
+func TestParseArticleHeaderAuthor(t *testing.T) {
+	app := newTestApplication()
+	art := &Article{Tags: []string{}, Aliases: []string{}, Params: map[string]interface{}{}}
+	for _, line := range []string{":author: Your name, Guest Writer", ":author: Editor"} {
+		if err := parseArticleHeader(app, art, line); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want := []string{"Your name", "Guest Writer", "Editor"}
+	if got := art.headerTerms("author"); !reflect.DeepEqual(got, want) {
+		t.Errorf("authors: %v", got)
+	}
+	if got := art.Params["author"]; !reflect.DeepEqual(got, want) {
+		t.Errorf("params: %v", got)
+	}
+}
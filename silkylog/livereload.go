@@ -0,0 +1,148 @@
+package silkylog
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+const liveReloadPath = "/_silkylog/livereload"
+
+const liveReloadScript = `<script>
+(function() {
+  var es = new EventSource("` + liveReloadPath + `");
+  es.onmessage = function() { es.close(); location.reload(); };
+})();
+</script>
+`
+
+// liveReloader notifies connected browsers of changes via Server-Sent Events.
+type liveReloader struct {
+	m       sync.Mutex
+	clients map[chan struct{}]struct{}
+}
+
+func newLiveReloader() *liveReloader {
+	return &liveReloader{
+		clients: make(map[chan struct{}]struct{}),
+	}
+}
+
+func (lr *liveReloader) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	ch := make(chan struct{}, 1)
+	lr.m.Lock()
+	lr.clients[ch] = struct{}{}
+	lr.m.Unlock()
+	defer func() {
+		lr.m.Lock()
+		delete(lr.clients, ch)
+		lr.m.Unlock()
+	}()
+
+	for {
+		select {
+		case <-ch:
+			if _, err := fmt.Fprint(w, "data: reload\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// Notify tells all connected browsers to reload.
+func (lr *liveReloader) Notify() {
+	lr.m.Lock()
+	defer lr.m.Unlock()
+	for ch := range lr.clients {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// Watch notifies browsers when files under the dir or the given files are
+// changed.
+func (lr *liveReloader) Watch(app *Application, dir string, files ...string) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	if err := addWatchTree(watcher, dir); err != nil {
+		_ = watcher.Close()
+		return err
+	}
+	targets := make(map[string]bool)
+	for _, file := range files {
+		file = filepath.Clean(file)
+		targets[file] = true
+		if err := watcher.Add(filepath.Dir(file)); err != nil {
+			_ = watcher.Close()
+			return err
+		}
+	}
+	go func() {
+		defer func() {
+			_ = watcher.Close()
+		}()
+		timer := time.NewTimer(watchDebounce)
+		timer.Stop()
+		for {
+			select {
+			case ev, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				name := filepath.Clean(ev.Name)
+				if !targets[name] && !isSubPath(dir, name) {
+					continue
+				}
+				if ev.Op&fsnotify.Create == fsnotify.Create && isDir(name) {
+					if err := addWatchTree(watcher, name); err != nil {
+						app.Log("livereload: %v", err)
+					}
+				}
+				timer.Reset(watchDebounce)
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				app.Log("livereload: %v", err)
+			case <-timer.C:
+				app.Debug("livereload: reload browsers")
+				lr.Notify()
+			}
+		}
+	}()
+	return nil
+}
+
+func injectLiveReload(html []byte) []byte {
+	idx := bytes.LastIndex(html, []byte("</body>"))
+	if idx < 0 {
+		return append(html, liveReloadScript...)
+	}
+	buf := make([]byte, 0, len(html)+len(liveReloadScript))
+	buf = append(buf, html[:idx]...)
+	buf = append(buf, liveReloadScript...)
+	return append(buf, html[idx:]...)
+}
@@ -1,9 +1,10 @@
-package main
+package silkylog
 
 import (
 	"bytes"
 	"html"
 	"os/exec"
+	"path/filepath"
 	"sync"
 
 	"github.com/yuin/gluamapper"
@@ -12,10 +13,18 @@ import (
 
 type lStatePool struct {
 	m     sync.Mutex
+	app   *Application
 	saved []*lua.LState
 }
 
-func (pl *lStatePool) Get() *lua.LState {
+func newLStatePool(app *Application) *lStatePool {
+	return &lStatePool{
+		app:   app,
+		saved: make([]*lua.LState, 0, 4),
+	}
+}
+
+func (pl *lStatePool) Get() (*lua.LState, error) {
 	pl.m.Lock()
 	defer pl.m.Unlock()
 	n := len(pl.saved)
@@ -24,13 +33,16 @@ func (pl *lStatePool) Get() *lua.LState {
 	}
 	x := pl.saved[n-1]
 	pl.saved = pl.saved[0 : n-1]
-	return x
+	return x, nil
 }
 
-func (pl *lStatePool) New() *lua.LState {
+func (pl *lStatePool) New() (*lua.LState, error) {
 	L := lua.NewState()
-	loadConfig(L)
-	return L
+	if _, err := pl.app.loadConfig(L); err != nil {
+		L.Close()
+		return nil, err
+	}
+	return L, nil
 }
 
 func (pl *lStatePool) Put(L *lua.LState) {
@@ -40,22 +52,27 @@ func (pl *lStatePool) Put(L *lua.LState) {
 }
 
 func (pl *lStatePool) Shutdown() {
+	pl.m.Lock()
+	defer pl.m.Unlock()
 	for _, L := range pl.saved {
 		L.Close()
 	}
+	pl.saved = pl.saved[:0]
 }
 
-var luaPool = &lStatePool{
-	saved: make([]*lua.LState, 0, 4),
-}
-
-// LuaModuleLoader loads lua functions.
-func LuaModuleLoader(L *lua.LState) int {
-	mod := L.SetFuncs(L.NewTable(), exports)
+// LuaModuleLoader loads lua functions bound to the Application.
+func (app *Application) LuaModuleLoader(L *lua.LState) int {
+	ud := L.NewUserData()
+	ud.Value = app
+	mod := L.SetFuncs(L.NewTable(), exports, ud)
 	L.Push(mod)
 	return 1
 }
 
+func luaApp(L *lua.LState) *Application {
+	return L.Get(lua.UpvalueIndex(1)).(*lua.LUserData).Value.(*Application)
+}
+
 var exports = map[string]lua.LGFunction{
 	"runprocessor": luaRunProcessor,
 	"htmlescape":   luaHTMLEscape,
@@ -127,7 +144,7 @@ func luaURLEncode(L *lua.LState) int {
 }
 
 func luaFormatMarkup(L *lua.LState) int {
-	app := appInstance()
+	app := luaApp(L)
 	text := L.CheckString(1)
 	format := L.CheckString(2)
 	html, err := app.convertArticleText(L, text, format)
@@ -141,7 +158,7 @@ func luaFormatMarkup(L *lua.LState) int {
 }
 
 func luaMapArg(L *lua.LState, idx int) map[interface{}]interface{} {
-	app := appInstance()
+	app := luaApp(L)
 	data := gluamapper.ToGoValue(L.CheckTable(idx),
 		gluamapper.Option{NameFunc: gluamapper.Id}).(map[interface{}]interface{})
 	data["App"] = app
@@ -149,40 +166,63 @@ func luaMapArg(L *lua.LState, idx int) map[interface{}]interface{} {
 }
 
 func luaTitle(L *lua.LState) int {
-	app := appInstance()
+	app := luaApp(L)
 	name := L.CheckString(1)
 	data := luaMapArg(L, 2)
-	L.Push(lua.LString(app.Title(name, data)))
+	title, err := app.Title(name, data)
+	if err != nil {
+		L.RaiseError("%v", err.Error())
+	}
+	L.Push(lua.LString(title))
 	return 1
 }
 
 func luaPath(L *lua.LState) int {
-	app := appInstance()
+	app := luaApp(L)
 	name := L.CheckString(1)
 	data := luaMapArg(L, 2)
-	L.Push(lua.LString(app.Path(name, data)))
+	path, err := app.Path(name, data)
+	if err != nil {
+		L.RaiseError("%v", err.Error())
+	}
+	L.Push(lua.LString(path))
 	return 1
 }
 
 func luaURL(L *lua.LState) int {
-	app := appInstance()
+	app := luaApp(L)
 	name := L.CheckString(1)
 	data := luaMapArg(L, 2)
-	L.Push(lua.LString(app.Url(name, data)))
+	url, err := app.Url(name, data)
+	if err != nil {
+		L.RaiseError("%v", err.Error())
+	}
+	L.Push(lua.LString(url))
 	return 1
 }
 
 func luaFullURL(L *lua.LState) int {
-	app := appInstance()
+	app := luaApp(L)
 	name := L.CheckString(1)
 	data := luaMapArg(L, 2)
-	L.Push(lua.LString(app.FullURL(name, data)))
+	url, err := app.FullURL(name, data)
+	if err != nil {
+		L.RaiseError("%v", err.Error())
+	}
+	L.Push(lua.LString(url))
 	return 1
 }
 
 func luaCopyFile(L *lua.LState) int {
-	src, dst := L.CheckString(1), L.CheckString(2)
-	if err := copyFile(src, dst); err != nil {
+	src, dst := L.CheckString(1), filepath.Clean(L.CheckString(2))
+	var err error
+	// files copied during a build are outputs of the build, so prune keeps them
+	if mf := luaApp(L).manifest; mf != nil {
+		_, err = mf.CopyFile(src, dst)
+	} else {
+		err = copyFile(src, dst)
+	}
+	if err != nil {
 		L.Push(lua.LNil)
 		L.Push(lua.LString(err.Error()))
 		return 2
@@ -192,8 +232,14 @@ func luaCopyFile(L *lua.LState) int {
 }
 
 func luaCopyTree(L *lua.LState) int {
-	src, dst := L.CheckString(1), L.CheckString(2)
-	if err := copyTree(src, dst); err != nil {
+	src, dst := L.CheckString(1), filepath.Clean(L.CheckString(2))
+	var err error
+	if mf := luaApp(L).manifest; mf != nil {
+		err = mf.CopyTree(src, dst)
+	} else {
+		err = copyTree(src, dst)
+	}
+	if err != nil {
 		L.Push(lua.LNil)
 		L.Push(lua.LString(err.Error()))
 		return 2
@@ -0,0 +1,257 @@
+package silkylog
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+const manifestFileName = ".silkylog-manifest.json"
+
+const manifestVersion = 2
+
+type manifestSource struct {
+	Hash        string `json:"hash"`
+	BodyHTML    string `json:"body_html"`
+	Summary     string `json:"summary"`
+	SummaryHTML string `json:"summary_html"`
+	HasMore     bool   `json:"has_more"`
+}
+
+// manifest records what the previous build produced so that unchanged
+// articles and pages can be skipped in the next build.
+type manifest struct {
+	m    sync.Mutex
+	path string
+	prev *manifestData
+	cur  *manifestData
+}
+
+type manifestData struct {
+	Version   int                       `json:"version"`
+	Config    map[string]string         `json:"config"`
+	Templates map[string]string         `json:"templates"`
+	Sources   map[string]manifestSource `json:"sources"`
+	Outputs   map[string]string         `json:"outputs"`
+	Related   manifestRelated           `json:"related"`
+}
+
+// manifestRelated records related articles by file path. Key is a hash of
+// the articles and the settings they were computed from.
+type manifestRelated struct {
+	Key      string              `json:"key"`
+	Articles map[string][]string `json:"articles"`
+}
+
+func newManifestData() *manifestData {
+	return &manifestData{
+		Version:   manifestVersion,
+		Config:    make(map[string]string),
+		Templates: make(map[string]string),
+		Sources:   make(map[string]manifestSource),
+		Outputs:   make(map[string]string),
+	}
+}
+
+// loadManifest reads and removes the manifest of the previous build. If full
+// is true or the manifest can not be read, the previous build is treated as
+// empty.
+func loadManifest(app *Application, full bool) (*manifest, error) {
+	mf := &manifest{
+		path: filepath.Join(app.outputDir, manifestFileName),
+		prev: newManifestData(),
+		cur:  newManifestData(),
+	}
+	bts, err := os.ReadFile(mf.path)
+	if err != nil {
+		return mf, nil
+	}
+	// A build that fails after overwriting some outputs never saves the
+	// manifest, so the previous one must not survive it, even in full builds.
+	if err := os.Remove(mf.path); err != nil {
+		return nil, err
+	}
+	if full {
+		return mf, nil
+	}
+	prev := newManifestData()
+	if err := json.Unmarshal(bts, prev); err != nil || prev.Version != manifestVersion {
+		app.Log("ignore broken build manifest: %v", mf.path)
+		return mf, nil
+	}
+	mf.prev = prev
+	return mf, nil
+}
+
+func hashString(s string) string {
+	sum := sha256.Sum256(([]byte)(s))
+	return hex.EncodeToString(sum[:])
+}
+
+func articleTextHash(art *Article) string {
+	return hashString(art.Format + "\n" + art.summarySource + "\n" + art.BodyText)
+}
+
+func hashFile(path string) (string, error) {
+	bts, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(bts)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// AddConfigFiles records hashes of the configuration files and the theme
+// templates.
+func (mf *manifest) AddConfigFiles(app *Application) error {
+	mf.m.Lock()
+	defer mf.m.Unlock()
+	themedir := filepath.Join(app.Config.ThemeDir, app.Config.Theme)
+	for _, path := range []string{app.configPath, filepath.Join(themedir, "theme.lua")} {
+		h, err := hashFile(path)
+		if err != nil {
+			return err
+		}
+		mf.cur.Config[path] = h
+	}
+	for _, dir := range []string{"layouts", "pages", "include", "feeds"} {
+		err := filepath.Walk(filepath.Join(themedir, dir), func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				if os.IsNotExist(err) {
+					return nil
+				}
+				return err
+			}
+			if info.IsDir() {
+				return nil
+			}
+			h, err := hashFile(path)
+			if err != nil {
+				return err
+			}
+			mf.cur.Templates[path] = h
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// HasPrevious returns true if the manifest of the previous build exists.
+func (mf *manifest) HasPrevious() bool {
+	return len(mf.prev.Config) != 0
+}
+
+// ConfigChanged returns true if the configuration files differ from the
+// previous build.
+func (mf *manifest) ConfigChanged() bool {
+	return !mapEquals(mf.prev.Config, mf.cur.Config)
+}
+
+// TemplatesChanged returns true if the theme templates differ from the
+// previous build.
+func (mf *manifest) TemplatesChanged() bool {
+	return !mapEquals(mf.prev.Templates, mf.cur.Templates)
+}
+
+// RestoreHTML sets the converted html of the previous build to the article
+// if neither the article source nor the configuration files have been changed
+// since the previous build. RestoreHTML returns true if the html was restored.
+func (mf *manifest) RestoreHTML(art *Article) bool {
+	mf.m.Lock()
+	defer mf.m.Unlock()
+	if mf.ConfigChanged() {
+		return false
+	}
+	src, ok := mf.prev.Sources[art.FilePath]
+	if !ok || src.Hash != articleTextHash(art) {
+		return false
+	}
+	art.BodyHTML = src.BodyHTML
+	art.Summary = src.Summary
+	art.SummaryHTML = src.SummaryHTML
+	art.HasMore = src.HasMore
+	return true
+}
+
+// AddSource records the converted html of the article.
+func (mf *manifest) AddSource(art *Article) {
+	mf.m.Lock()
+	defer mf.m.Unlock()
+	mf.cur.Sources[art.FilePath] = manifestSource{
+		Hash:        articleTextHash(art),
+		BodyHTML:    art.BodyHTML,
+		Summary:     art.Summary,
+		SummaryHTML: art.SummaryHTML,
+		HasMore:     art.HasMore,
+	}
+}
+
+// WriteFile writes the data to the path unless the previous build wrote the
+// same data to the path. WriteFile returns true if the file was written.
+func (mf *manifest) WriteFile(data, path string) (bool, error) {
+	h := hashString(data)
+	mf.m.Lock()
+	prev, ok := mf.prev.Outputs[path]
+	mf.cur.Outputs[path] = h
+	mf.m.Unlock()
+	if ok && prev == h && isFile(path) {
+		return false, nil
+	}
+	return true, writeFile(data, path)
+}
+
+// CopyFile copies the source file to the dest like WriteFile.
+func (mf *manifest) CopyFile(source, dest string) (bool, error) {
+	data, err := os.ReadFile(source)
+	if err != nil {
+		return false, err
+	}
+	return mf.WriteFile(string(data), dest)
+}
+
+// CopyTree copies the source directory to the dest like WriteFile.
+func (mf *manifest) CopyTree(source, dest string) error {
+	return filepath.Walk(source, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(source, path)
+		if err != nil {
+			return err
+		}
+		_, err = mf.CopyFile(path, filepath.Join(dest, rel))
+		return err
+	})
+}
+
+// Save writes the manifest of the current build.
+func (mf *manifest) Save() error {
+	mf.m.Lock()
+	defer mf.m.Unlock()
+	bts, err := json.Marshal(mf.cur)
+	if err != nil {
+		return err
+	}
+	return writeFile(string(bts), mf.path)
+}
+
+func mapEquals(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for key, va := range a {
+		if vb, ok := b[key]; !ok || va != vb {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,94 @@
+package silkylog
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// checkPages returns an error if the site has pages but does not configure
+// where to write them. Without page_url_path, pages would overwrite files in
+// the root of the output directory such as index.html.
+func (cfg *Config) checkPages() error {
+	basedir := filepath.Join(cfg.ContentDir, "pages")
+	if isDir(basedir) && len(cfg.PageUrlPath) == 0 {
+		return fmt.Errorf("page_url_path is required to build pages in %v", basedir)
+	}
+	return nil
+}
+
+// LoadPage loads the standalone page at the path. Pages are written in markup
+// like articles, but are not listed in indexes, tags, archives and feeds.
+func (app *Application) LoadPage(path string) (*Article, error) {
+	art, err := app.loadArticleFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(art.Slug) == 0 {
+		rel, err := filepath.Rel(filepath.Join(app.Config.ContentDir, "pages"), path)
+		if err != nil {
+			rel = filepath.Base(path)
+		}
+		art.Slug = filepath.ToSlash(strings.TrimSuffix(rel, filepath.Ext(rel)))
+	}
+	if len(art.Status) == 0 {
+		art.Status = "published"
+	}
+	completeArticleHeader(art)
+	if err := app.setPermlink(art, "Page"); err != nil {
+		return nil, err
+	}
+	return art, nil
+}
+
+// LoadPages loads pages that have the status.
+func (app *Application) LoadPages(status string) error {
+	basedir := filepath.Join(app.Config.ContentDir, "pages")
+	if !isDir(basedir) {
+		return nil
+	}
+	pages, errs, err := loadContents(basedir, app.LoadPage, status, nil)
+	if err != nil {
+		return err
+	}
+	if len(errs) != 0 {
+		return fmt.Errorf("failed to load %d pages:\n%w", len(errs), errs)
+	}
+	sort.Slice(pages, func(i, j int) bool { return pages[i].Slug < pages[j].Slug })
+	// pages have authors but are not listed in archives of the authors
+	for _, page := range pages {
+		app.setAuthors(page)
+	}
+	app.Pages = pages
+	return nil
+}
+
+func buildPage(app *Application, renderer *renderer, art *Article) error {
+	app.Stats.Inc("Page")
+	app.Debug("page: %v", art.FilePath)
+	if app.manifest.RestoreHTML(art) {
+		app.Stats.Inc("Cached")
+	}
+	if err := app.ConvertArticleText(art); err != nil {
+		return fmt.Errorf("%v: %w", art.FilePath, err)
+	}
+	app.manifest.AddSource(art)
+	title, err := app.Title("Page", H("App", app, "Article", art))
+	if err != nil {
+		return fmt.Errorf("%v: %w", art.FilePath, err)
+	}
+	html, err := renderer.RenderPage(app, app.themePage("page", "article"), newViewModel(app, title, art))
+	if err != nil {
+		return fmt.Errorf("%v: %w", art.FilePath, err)
+	}
+	path, err := app.Path("Page", art)
+	if err != nil {
+		return fmt.Errorf("%v: %w", art.FilePath, err)
+	}
+	if err := writeOutput(app, html, filepath.Join(app.outputDir, path)); err != nil {
+		return fmt.Errorf("%v: %w", art.FilePath, err)
+	}
+	app.sitemap.Add(app, path, art.UpdatedAt)
+	return nil
+}
@@ -0,0 +1,102 @@
+package silkylog
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// PruneConfig configures pruning of stale output files.
+type PruneConfig struct {
+	// Keep is a list of glob patterns of output paths that are never pruned.
+	// A pattern matching a directory keeps everything in it.
+	Keep []string
+}
+
+// IsOutput returns true if the current build wrote the path.
+func (mf *manifest) IsOutput(path string) bool {
+	mf.m.Lock()
+	defer mf.m.Unlock()
+	_, ok := mf.cur.Outputs[path]
+	return ok || path == mf.path
+}
+
+// isKept returns true if the output path or one of its parent directories
+// matches the keep patterns.
+func isKept(app *Application, rel string) bool {
+	parts := strings.Split(filepath.ToSlash(rel), "/")
+	for i := range parts {
+		p := strings.Join(parts[:i+1], "/")
+		for _, pattern := range app.Config.Prune.Keep {
+			if ok, _ := path.Match(pattern, p); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// prune deletes files in the output directory that were not written by the
+// current build, and then empty directories. If dryRun is true, prune only
+// reports them.
+func prune(app *Application, dryRun bool) error {
+	odir := app.outputDir
+	stales := []string{}
+	dirs := []string{}
+	err := filepath.Walk(odir, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if p == odir {
+			return nil
+		}
+		rel, err := filepath.Rel(odir, p)
+		if err != nil {
+			return err
+		}
+		if isKept(app, rel) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if info.IsDir() {
+			dirs = append(dirs, p)
+		} else if !app.manifest.IsOutput(p) {
+			stales = append(stales, p)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, p := range stales {
+		app.Stats.Inc("Pruned")
+		if dryRun {
+			app.Log("stale: %v", p)
+			continue
+		}
+		app.Debug("prune: %v", p)
+		if err := os.Remove(p); err != nil {
+			return err
+		}
+	}
+	if dryRun {
+		app.Log("%d stale files(dry run)", app.Stats.Get("Pruned"))
+		return nil
+	}
+	// remove deeper directories first
+	sort.Sort(sort.Reverse(sort.StringSlice(dirs)))
+	for _, dir := range dirs {
+		if entries, err := os.ReadDir(dir); err == nil && len(entries) == 0 {
+			app.Debug("prune: %v", dir)
+			if err := os.Remove(dir); err != nil {
+				return err
+			}
+		}
+	}
+	app.Log("%d stale files pruned", app.Stats.Get("Pruned"))
+	return nil
+}
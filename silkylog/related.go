@@ -0,0 +1,312 @@
+package silkylog
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const defaultRelatedCount = 5
+
+// RelatedConfig configures related articles.
+type RelatedConfig struct {
+	// Count is a maximum number of related articles.
+	Count int
+	// TagWeight is a weight of shared tags.
+	TagWeight float64
+	// TextWeight is a weight of TF-IDF similarity of article texts. 0 disables it.
+	TextWeight float64
+}
+
+type relatedScore struct {
+	idx   int
+	score float64
+}
+
+// relatedTerms is a maximum number of terms of a TF-IDF vector. Rare terms
+// of an article tell most about it, and short vectors keep scoring fast.
+const relatedTerms = 64
+
+type relatedPosting struct {
+	idx int
+	w   float64
+}
+
+// computeRelated sets related articles of the loaded articles. Articles are
+// scored by shared tags, rare tags weighing more, and optionally by TF-IDF
+// similarity of their texts. Results are restored from the build manifest
+// if neither the articles nor the settings have been changed.
+func (app *Application) computeRelated() {
+	cfg := app.Config.Related
+	count := cfg.Count
+	if count == 0 {
+		count = defaultRelatedCount
+	}
+	tagWeight, textWeight := cfg.TagWeight, cfg.TextWeight
+	if tagWeight == 0 && textWeight == 0 {
+		tagWeight = 1
+	}
+	for _, art := range app.Articles {
+		art.Related = Articles{}
+	}
+	if count < 0 {
+		return
+	}
+	key := relatedKey(app.Articles, count, tagWeight, textWeight)
+	if app.manifest != nil {
+		if app.manifest.RestoreRelated(key, app.Articles) {
+			return
+		}
+		defer app.manifest.AddRelated(key, app.Articles)
+	}
+
+	n := float64(len(app.Articles))
+	index := make(map[*Article]int, len(app.Articles))
+	for i, art := range app.Articles {
+		index[art] = i
+	}
+	tagIDF := map[string]float64{}
+	for tag, arts := range app.Tags {
+		tagIDF[tag] = math.Log(1 + n/float64(len(arts)))
+	}
+	postings := map[string][]relatedPosting{}
+	var vectors []map[string]float64
+	if textWeight > 0 {
+		vectors = tfidfVectors(app.Articles, app.Config.NumThreads)
+		for i, vec := range vectors {
+			for token, w := range vec {
+				postings[token] = append(postings[token], relatedPosting{i, w})
+			}
+		}
+	}
+
+	runWorkers(app.Config.NumThreads, len(app.Articles), func() func(int) {
+		scores := make([]float64, len(app.Articles))
+		marked := make([]bool, len(app.Articles))
+		touched := []int{}
+		return func(i int) {
+			art := app.Articles[i]
+			touched = touched[:0]
+			add := func(j int, score float64) {
+				if !marked[j] {
+					marked[j] = true
+					touched = append(touched, j)
+				}
+				scores[j] += score
+			}
+			if tagWeight != 0 {
+				seen := map[int]bool{i: true}
+				for _, tag := range art.Tags {
+					for _, other := range app.Tags[tag] {
+						if j := index[other]; !seen[j] {
+							seen[j] = true
+							add(j, tagWeight*tagSimilarity(art, other, tagIDF))
+						}
+					}
+				}
+			}
+			if vectors != nil {
+				for token, w := range vectors[i] {
+					for _, p := range postings[token] {
+						if p.idx != i {
+							add(p.idx, textWeight*w*p.w)
+						}
+					}
+				}
+			}
+			// keep the best count articles, newer ones win ties because
+			// articles are sorted by posted date
+			ranked := make([]relatedScore, 0, count+1)
+			for _, j := range touched {
+				score := scores[j]
+				scores[j], marked[j] = 0, false
+				if score <= 0 {
+					continue
+				}
+				k := len(ranked)
+				for k > 0 && (ranked[k-1].score < score || ranked[k-1].score == score && ranked[k-1].idx > j) {
+					k--
+				}
+				if k < count {
+					ranked = append(ranked, relatedScore{})
+					copy(ranked[k+1:], ranked[k:])
+					ranked[k] = relatedScore{j, score}
+					if len(ranked) > count {
+						ranked = ranked[:count]
+					}
+				}
+			}
+			related := make(Articles, 0, len(ranked))
+			for _, r := range ranked {
+				related = append(related, app.Articles[r.idx])
+			}
+			art.Related = related
+		}
+	})
+}
+
+// runWorkers calls a function made by newWorker with every index in [0, n)
+// on threads goroutines. Each goroutine makes its own function, so the
+// function can reuse scratch buffers.
+func runWorkers(threads, n int, newWorker func() func(int)) {
+	if threads <= 0 {
+		threads = 1
+	}
+	idxch := make(chan int)
+	var wg sync.WaitGroup
+	for t := 0; t < threads; t++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			work := newWorker()
+			for i := range idxch {
+				work(i)
+			}
+		}()
+	}
+	for i := 0; i < n; i++ {
+		idxch <- i
+	}
+	close(idxch)
+	wg.Wait()
+}
+
+// relatedKey returns a hash of everything related articles depend on.
+func relatedKey(arts Articles, count int, tagWeight, textWeight float64) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d %v %v %d\n", count, tagWeight, textWeight, relatedTerms)
+	for _, art := range arts {
+		fmt.Fprintf(&b, "%v\t%v\t%v\n", art.FilePath, hashString(art.BodyText), strings.Join(art.Tags, ","))
+	}
+	return hashString(b.String())
+}
+
+// RestoreRelated sets related articles of the previous build to the articles
+// if the key matches. RestoreRelated returns true if they were restored.
+func (mf *manifest) RestoreRelated(key string, arts Articles) bool {
+	mf.m.Lock()
+	defer mf.m.Unlock()
+	prev := mf.prev.Related
+	if prev.Key != key {
+		return false
+	}
+	byPath := make(map[string]*Article, len(arts))
+	for _, art := range arts {
+		byPath[art.FilePath] = art
+	}
+	for _, art := range arts {
+		paths, ok := prev.Articles[art.FilePath]
+		if !ok {
+			return false
+		}
+		related := Articles{}
+		for _, path := range paths {
+			rart, ok := byPath[path]
+			if !ok {
+				return false
+			}
+			related = append(related, rart)
+		}
+		art.Related = related
+	}
+	mf.cur.Related = prev
+	return true
+}
+
+// AddRelated records related articles of the articles.
+func (mf *manifest) AddRelated(key string, arts Articles) {
+	mf.m.Lock()
+	defer mf.m.Unlock()
+	related := manifestRelated{Key: key, Articles: make(map[string][]string, len(arts))}
+	for _, art := range arts {
+		paths := make([]string, 0, len(art.Related))
+		for _, rart := range art.Related {
+			paths = append(paths, rart.FilePath)
+		}
+		related.Articles[art.FilePath] = paths
+	}
+	mf.cur.Related = related
+}
+
+// tagSimilarity returns the weighted Jaccard similarity of tags of the articles.
+func tagSimilarity(a, b *Article, idf map[string]float64) float64 {
+	union, shared := 0.0, 0.0
+	seen := map[string]bool{}
+	for _, tag := range a.Tags {
+		seen[tag] = true
+		union += idf[tag]
+	}
+	for _, tag := range b.Tags {
+		if seen[tag] {
+			shared += idf[tag]
+		} else {
+			union += idf[tag]
+		}
+	}
+	if union == 0 {
+		return 0
+	}
+	return shared / union
+}
+
+// tfidfVectors returns normalized TF-IDF vectors of the top relatedTerms
+// terms of the articles.
+func tfidfVectors(arts Articles, threads int) []map[string]float64 {
+	tfs := make([]map[string]float64, len(arts))
+	runWorkers(threads, len(arts), func() func(int) {
+		return func(i int) {
+			tf := map[string]float64{}
+			for _, token := range tokenize(arts[i].BodyText) {
+				tf[token]++
+			}
+			tfs[i] = tf
+		}
+	})
+	df := map[string]int{}
+	for _, tf := range tfs {
+		for token := range tf {
+			df[token]++
+		}
+	}
+	n := float64(len(arts))
+	runWorkers(threads, len(arts), func() func(int) {
+		return func(i int) {
+			tfs[i] = topTerms(tfs[i], df, n)
+		}
+	})
+	return tfs
+}
+
+// topTerms weighs the term frequencies by IDF and returns the normalized
+// vector of the top relatedTerms terms.
+func topTerms(tf map[string]float64, df map[string]int, n float64) map[string]float64 {
+	terms := make([]relatedPosting, 0, len(tf))
+	tokens := make([]string, 0, len(tf))
+	for token, f := range tf {
+		if w := f * math.Log(n/float64(df[token])); w > 0 {
+			terms = append(terms, relatedPosting{len(tokens), w})
+			tokens = append(tokens, token)
+		}
+	}
+	sort.Slice(terms, func(a, b int) bool {
+		if terms[a].w != terms[b].w {
+			return terms[a].w > terms[b].w
+		}
+		return tokens[terms[a].idx] < tokens[terms[b].idx]
+	})
+	if len(terms) > relatedTerms {
+		terms = terms[:relatedTerms]
+	}
+	norm := 0.0
+	for _, term := range terms {
+		norm += term.w * term.w
+	}
+	norm = math.Sqrt(norm)
+	vec := make(map[string]float64, len(terms))
+	for _, term := range terms {
+		vec[tokens[term.idx]] = term.w / norm
+	}
+	return vec
+}
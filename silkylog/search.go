@@ -0,0 +1,161 @@
+package silkylog
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+var defaultSearchFields = []string{"title", "url", "tags", "date", "summary", "tokens"}
+
+// SearchConfig configures the client-side search index.
+type SearchConfig struct {
+	// Name is a name of the search index. An empty name disables the index.
+	Name string
+	// Fields are fields of index entries: title, url, tags, date, summary,
+	// body and tokens.
+	Fields []string
+	// BodySize is a maximum number of characters of body.
+	BodySize int
+	// Size is a maximum number of articles in the index. 0 means all articles.
+	Size int
+	// ShardByYear splits the index into a file per year.
+	ShardByYear bool
+}
+
+type searchDoc struct {
+	Title   string   `json:"title,omitempty"`
+	URL     string   `json:"url,omitempty"`
+	Tags    []string `json:"tags,omitempty"`
+	Date    string   `json:"date,omitempty"`
+	Summary string   `json:"summary,omitempty"`
+	Body    string   `json:"body,omitempty"`
+	Tokens  []string `json:"tokens,omitempty"`
+}
+
+type searchIndex struct {
+	Docs []*searchDoc `json:"docs"`
+}
+
+type searchShard struct {
+	Year int    `json:"year"`
+	URL  string `json:"url"`
+}
+
+type searchShards struct {
+	Shards []*searchShard `json:"shards"`
+}
+
+func (app *Application) searchDoc(art *Article, fields map[string]bool) *searchDoc {
+	doc := &searchDoc{}
+	if fields["title"] {
+		doc.Title = art.Title
+	}
+	if fields["url"] {
+		doc.URL = art.PermlinkPath
+	}
+	if fields["tags"] {
+		doc.Tags = art.Tags
+	}
+	if fields["date"] {
+		doc.Date = art.PostedAt.Format(time.RFC3339)
+	}
+	if fields["summary"] {
+		doc.Summary = art.Summary
+	}
+	text := stripHTML(art.BodyHTML)
+	if fields["body"] {
+		doc.Body = text
+		if size := app.Config.Search.BodySize; size > 0 {
+			if runes := []rune(text); len(runes) > size {
+				doc.Body = string(runes[:size])
+			}
+		}
+	}
+	if fields["tokens"] {
+		seen := map[string]bool{}
+		for _, token := range tokenize(art.Title + " " + strings.Join(art.Tags, " ") + " " + text) {
+			if !seen[token] {
+				seen[token] = true
+				doc.Tokens = append(doc.Tokens, token)
+			}
+		}
+		sort.Strings(doc.Tokens)
+	}
+	return doc
+}
+
+func writeSearchJSON(app *Application, v interface{}, name string) error {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return fmt.Errorf("%v: %w", name, err)
+	}
+	app.Stats.Inc("Search")
+	if err := writeOutput(app, buf.String(), filepath.Join(app.outputDir, name)); err != nil {
+		return fmt.Errorf("%v: %w", name, err)
+	}
+	return nil
+}
+
+func buildSearchIndex(app *Application) error {
+	cfg := app.Config.Search
+	if len(cfg.Name) == 0 {
+		return nil
+	}
+	fields := map[string]bool{}
+	names := cfg.Fields
+	if len(names) == 0 {
+		names = defaultSearchFields
+	}
+	for _, name := range names {
+		fields[name] = true
+	}
+	arts := app.Articles
+	if cfg.Size > 0 {
+		arts = arts.SubList(0, cfg.Size)
+	}
+
+	if !cfg.ShardByYear {
+		index := &searchIndex{Docs: []*searchDoc{}}
+		for _, art := range arts {
+			index.Docs = append(index.Docs, app.searchDoc(art, fields))
+		}
+		if err := writeSearchJSON(app, index, cfg.Name); err != nil {
+			return err
+		}
+	} else {
+		ext := path.Ext(cfg.Name)
+		base := strings.TrimSuffix(cfg.Name, ext)
+		shards := &searchShards{Shards: []*searchShard{}}
+		years := map[int]*searchIndex{}
+		for _, art := range arts {
+			year := art.PostedAt.Year()
+			index, ok := years[year]
+			if !ok {
+				index = &searchIndex{Docs: []*searchDoc{}}
+				years[year] = index
+				name := fmt.Sprintf("%v-%04d%v", base, year, ext)
+				shards.Shards = append(shards.Shards, &searchShard{Year: year, URL: "/" + urlEncode(name)})
+			}
+			index.Docs = append(index.Docs, app.searchDoc(art, fields))
+		}
+		for _, shard := range shards.Shards {
+			name := fmt.Sprintf("%v-%04d%v", base, shard.Year, ext)
+			if err := writeSearchJSON(app, years[shard.Year], name); err != nil {
+				return err
+			}
+		}
+		if err := writeSearchJSON(app, shards, cfg.Name); err != nil {
+			return err
+		}
+	}
+	app.Log("%d search index files", app.Stats.Get("Search"))
+	return nil
+}
@@ -0,0 +1,104 @@
+package silkylog
+
+import (
+	"sort"
+)
+
+// Series is a list of articles that have the same series header in reading
+// order (see seriesOrder).
+type Series struct {
+	Name     string
+	Articles Articles
+}
+
+// computeSeries groups the loaded articles by series and links parts of
+// each series.
+func (app *Application) computeSeries() {
+	app.Series = make(map[string]*Series)
+	for _, art := range app.Articles {
+		art.Series, art.SeriesPart, art.SeriesPrev, art.SeriesNext = nil, 0, nil, nil
+		if len(art.seriesName) == 0 {
+			continue
+		}
+		series, ok := app.Series[art.seriesName]
+		if !ok {
+			series = &Series{Name: art.seriesName, Articles: Articles{}}
+			app.Series[art.seriesName] = series
+		}
+		series.Articles = append(series.Articles, art)
+	}
+	for _, series := range app.Series {
+		series.Articles = seriesOrder(series.Articles)
+		arts := series.Articles
+		for i, art := range arts {
+			art.Series = series
+			art.SeriesPart = i + 1
+			if i > 0 {
+				art.SeriesPrev = arts[i-1]
+			}
+			if i+1 < len(arts) {
+				art.SeriesNext = arts[i+1]
+			}
+		}
+	}
+}
+
+// seriesOrder sorts parts of a series. Parts with series_order are sorted by
+// it and then by posted date. Parts without it are placed among them by posted
+// date, before the first numbered part posted after them.
+func seriesOrder(arts Articles) Articles {
+	numbered, dated := Articles{}, Articles{}
+	for _, art := range arts {
+		if art.SeriesOrder == 0 {
+			dated = append(dated, art)
+		} else {
+			numbered = append(numbered, art)
+		}
+	}
+	sort.SliceStable(numbered, func(i, j int) bool {
+		if numbered[i].SeriesOrder != numbered[j].SeriesOrder {
+			return numbered[i].SeriesOrder < numbered[j].SeriesOrder
+		}
+		return numbered[i].PostedAt.Before(numbered[j].PostedAt)
+	})
+	sort.SliceStable(dated, func(i, j int) bool {
+		return dated[i].PostedAt.Before(dated[j].PostedAt)
+	})
+	sorted := make(Articles, 0, len(arts))
+	for len(numbered) != 0 || len(dated) != 0 {
+		if len(dated) != 0 && (len(numbered) == 0 || dated[0].PostedAt.Before(numbered[0].PostedAt)) {
+			sorted, dated = append(sorted, dated[0]), dated[1:]
+		} else {
+			sorted, numbered = append(sorted, numbered[0]), numbered[1:]
+		}
+	}
+	return sorted
+}
+
+// buildSeries renders index pages of the series in reading order.
+func buildSeries(app *Application, renderer *renderer) error {
+	if len(app.Config.SeriesUrlPath) == 0 {
+		return nil
+	}
+	terms := ArticleMap{}
+	for name, series := range app.Series {
+		terms[name] = series.Articles
+	}
+	if err := buildTermLists(app, renderer, app.themePage("series", "list2"), "Series", "Series", terms,
+		func(vm *viewModel, name string) {
+			vm.Series = app.Series[name]
+		}); err != nil {
+		return err
+	}
+	app.Log("%d series pages", app.Stats.Get("Series"))
+	return nil
+}
+
+// SeriesUrl returns an URL of the index page of the series, or an empty
+// string when the site has no series pages.
+func (app *Application) SeriesUrl(name string) (string, error) {
+	if len(app.Config.SeriesUrlPath) == 0 {
+		return "", nil
+	}
+	return app.Url("Series", H("Series", name, "Page", 0))
+}
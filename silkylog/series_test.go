@@ -0,0 +1,63 @@
+package silkylog
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSeriesOrder(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2023, 1, d, 0, 0, 0, 0, time.UTC) }
+	cases := []struct {
+		name string
+		arts []*Article
+		want []string
+	}{
+		{
+			name: "series_order",
+			arts: []*Article{
+				{Slug: "b", SeriesOrder: 2, PostedAt: day(1)},
+				{Slug: "a", SeriesOrder: 1, PostedAt: day(2)},
+			},
+			want: []string{"a", "b"},
+		},
+		{
+			name: "posted date",
+			arts: []*Article{
+				{Slug: "b", PostedAt: day(2)},
+				{Slug: "a", PostedAt: day(1)},
+			},
+			want: []string{"a", "b"},
+		},
+		{
+			name: "unnumbered parts are placed by posted date",
+			arts: []*Article{
+				{Slug: "two", SeriesOrder: 2, PostedAt: day(5)},
+				{Slug: "hello", PostedAt: day(1)},
+				{Slug: "one", SeriesOrder: 1, PostedAt: day(3)},
+				{Slug: "later", PostedAt: day(4)},
+				{Slug: "last", PostedAt: day(9)},
+			},
+			want: []string{"hello", "one", "later", "two", "last"},
+		},
+		{
+			name: "same series_order",
+			arts: []*Article{
+				{Slug: "b", SeriesOrder: 1, PostedAt: day(2)},
+				{Slug: "a", SeriesOrder: 1, PostedAt: day(1)},
+			},
+			want: []string{"a", "b"},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got := []string{}
+			for _, art := range seriesOrder(c.arts) {
+				got = append(got, art.Slug)
+			}
+			if !reflect.DeepEqual(got, c.want) {
+				t.Errorf("got %v, want %v", got, c.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,193 @@
+package silkylog
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// sitemapMaxURLs is a maximum number of URLs in a sitemap file. Larger
+// sitemaps are split into files referenced by a sitemap index.
+const sitemapMaxURLs = 50000
+
+const sitemapNamespace = "http://www.sitemaps.org/schemas/sitemap/0.9"
+
+// SitemapConfig configures sitemap.xml and robots.txt.
+type SitemapConfig struct {
+	// Name is a name of the sitemap. An empty name disables the sitemap.
+	Name string
+	// Exclude is a list of glob patterns of output paths excluded from the sitemap.
+	Exclude []string
+	// Robots is a name of the robots.txt. An empty name disables the robots.txt.
+	Robots string
+	// RobotsText is rules written into the robots.txt.
+	RobotsText string
+}
+
+type sitemapURL struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod,omitempty"`
+}
+
+type sitemapURLSet struct {
+	XMLName xml.Name      `xml:"urlset"`
+	Xmlns   string        `xml:"xmlns,attr"`
+	URLs    []*sitemapURL `xml:"url"`
+}
+
+type sitemapIndex struct {
+	XMLName  xml.Name      `xml:"sitemapindex"`
+	Xmlns    string        `xml:"xmlns,attr"`
+	Sitemaps []*sitemapURL `xml:"sitemap"`
+}
+
+type sitemapEntry struct {
+	loc     string
+	lastmod time.Time
+}
+
+// sitemap collects pages written by a build.
+type sitemap struct {
+	m       sync.Mutex
+	entries map[string]time.Time
+}
+
+func newSitemap() *sitemap {
+	return &sitemap{entries: make(map[string]time.Time)}
+}
+
+// Add adds the page at the output path to the sitemap.
+func (sm *sitemap) Add(app *Application, opath string, lastmod time.Time) {
+	opath = filepath.ToSlash(opath)
+	for _, pattern := range app.Config.Sitemap.Exclude {
+		if ok, _ := path.Match(pattern, opath); ok {
+			return
+		}
+	}
+	url := strings.TrimSuffix(opath, "index.html")
+	if app.Config.TrimHTML {
+		url = strings.TrimSuffix(url, ".html")
+	}
+	loc := app.Config.SiteUrl + urlEncode(url)
+	sm.m.Lock()
+	defer sm.m.Unlock()
+	if t, ok := sm.entries[loc]; !ok || lastmod.After(t) {
+		sm.entries[loc] = lastmod
+	}
+}
+
+func (sm *sitemap) sortedEntries() []sitemapEntry {
+	entries := make([]sitemapEntry, 0, len(sm.entries))
+	for loc, lastmod := range sm.entries {
+		entries = append(entries, sitemapEntry{loc, lastmod})
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].loc < entries[j].loc })
+	return entries
+}
+
+func formatSitemapTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
+
+func encodeSitemapXML(v interface{}) (string, error) {
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	enc := xml.NewEncoder(&buf)
+	enc.Indent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return "", err
+	}
+	buf.WriteString("\n")
+	return buf.String(), nil
+}
+
+func writeSitemapURLs(app *Application, entries []sitemapEntry, name string) error {
+	set := &sitemapURLSet{Xmlns: sitemapNamespace}
+	for _, e := range entries {
+		set.URLs = append(set.URLs, &sitemapURL{Loc: e.loc, LastMod: formatSitemapTime(e.lastmod)})
+	}
+	data, err := encodeSitemapXML(set)
+	if err != nil {
+		return fmt.Errorf("%v: %w", name, err)
+	}
+	app.Stats.Inc("Sitemap")
+	if err := writeOutput(app, data, filepath.Join(app.outputDir, name)); err != nil {
+		return fmt.Errorf("%v: %w", name, err)
+	}
+	return nil
+}
+
+func buildSitemap(app *Application) error {
+	cfg := app.Config.Sitemap
+	if len(cfg.Name) == 0 {
+		return nil
+	}
+	entries := app.sitemap.sortedEntries()
+	if len(entries) <= sitemapMaxURLs {
+		if err := writeSitemapURLs(app, entries, cfg.Name); err != nil {
+			return err
+		}
+	} else {
+		ext := path.Ext(cfg.Name)
+		base := strings.TrimSuffix(cfg.Name, ext)
+		index := &sitemapIndex{Xmlns: sitemapNamespace}
+		for i := 0; i*sitemapMaxURLs < len(entries); i++ {
+			chunk := entries[i*sitemapMaxURLs : intMin((i+1)*sitemapMaxURLs, len(entries))]
+			name := fmt.Sprintf("%v-%d%v", base, i+1, ext)
+			if err := writeSitemapURLs(app, chunk, name); err != nil {
+				return err
+			}
+			lastmod := time.Time{}
+			for _, e := range chunk {
+				if e.lastmod.After(lastmod) {
+					lastmod = e.lastmod
+				}
+			}
+			index.Sitemaps = append(index.Sitemaps, &sitemapURL{
+				Loc:     app.Config.SiteUrl + urlEncode(name),
+				LastMod: formatSitemapTime(lastmod),
+			})
+		}
+		data, err := encodeSitemapXML(index)
+		if err != nil {
+			return fmt.Errorf("%v: %w", cfg.Name, err)
+		}
+		app.Stats.Inc("Sitemap")
+		if err := writeOutput(app, data, filepath.Join(app.outputDir, cfg.Name)); err != nil {
+			return fmt.Errorf("%v: %w", cfg.Name, err)
+		}
+	}
+	app.Log("%d sitemap files(%d urls)", app.Stats.Get("Sitemap"), len(entries))
+	return nil
+}
+
+func buildRobots(app *Application) error {
+	cfg := app.Config.Sitemap
+	if len(cfg.Robots) == 0 {
+		return nil
+	}
+	var buf strings.Builder
+	if text := strings.TrimSpace(cfg.RobotsText); len(text) != 0 {
+		buf.WriteString(text)
+		buf.WriteString("\n")
+	}
+	if len(cfg.Name) != 0 {
+		if buf.Len() != 0 {
+			buf.WriteString("\n")
+		}
+		fmt.Fprintf(&buf, "Sitemap: %v%v\n", app.Config.SiteUrl, urlEncode(cfg.Name))
+	}
+	if err := writeOutput(app, buf.String(), filepath.Join(app.outputDir, cfg.Robots)); err != nil {
+		return fmt.Errorf("%v: %w", cfg.Robots, err)
+	}
+	return nil
+}
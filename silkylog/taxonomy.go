@@ -0,0 +1,166 @@
+package silkylog
+
+import (
+	"errors"
+	"fmt"
+	htemplate "html/template"
+	"reflect"
+	"strings"
+	"text/template"
+)
+
+// TaxonomyConfig declares a taxonomy like categories. Articles list their
+// terms in the header, and list pages are rendered for each term.
+type TaxonomyConfig struct {
+	// Name is a name of the taxonomy used with App.Url and App.Taxonomies.
+	Name string
+	// Header is a header name of terms. It defaults to the name.
+	Header string
+	// UrlPath is a url_path of list pages. It receives .Term and .Page.
+	UrlPath string
+	// Title is a title of list pages. It receives .Term and .Page.
+	Title string
+	// Template is a page template of list pages. It defaults to list2.
+	Template string
+}
+
+func (tx *TaxonomyConfig) header() string {
+	if len(tx.Header) == 0 {
+		return tx.Name
+	}
+	return tx.Header
+}
+
+func (tx *TaxonomyConfig) template() string {
+	if len(tx.Template) == 0 {
+		return "list2"
+	}
+	return tx.Template
+}
+
+// buildCounters are Stats counters of builds other than url_path names.
+var buildCounters = []string{"Alias", "Cached", "Extra", "Pruned", "Search", "Sitemap", "Unchanged"}
+
+// reservedTaxonomyNames returns lower-cased names that taxonomies can not
+// use because builtin url_path and title templates and Stats counters
+// already use them.
+func reservedTaxonomyNames() map[string]bool {
+	names := map[string]bool{}
+	rt := reflect.TypeOf(Config{})
+	for i := 0; i < rt.NumField(); i++ {
+		name := rt.Field(i).Name
+		if strings.HasSuffix(name, "UrlPath") {
+			names[strings.ToLower(strings.TrimSuffix(name, "UrlPath"))] = true
+		} else if strings.HasSuffix(name, "Title") {
+			names[strings.ToLower(strings.TrimSuffix(name, "Title"))] = true
+		}
+	}
+	for _, name := range buildCounters {
+		names[strings.ToLower(name)] = true
+	}
+	return names
+}
+
+func (app *Application) compileTaxonomyTemplates() error {
+	seen := map[string]bool{}
+	reserved := reservedTaxonomyNames()
+	for _, tx := range app.Config.Taxonomies {
+		if len(tx.Name) == 0 {
+			return errors.New("taxonomies: name is required")
+		}
+		if reserved[strings.ToLower(tx.Name)] {
+			return errors.New("taxonomies: reserved name: " + tx.Name)
+		}
+		if seen[tx.Name] {
+			return errors.New("taxonomies: duplicated name: " + tx.Name)
+		}
+		seen[tx.Name] = true
+		if len(tx.UrlPath) == 0 {
+			return errors.New("taxonomies: url_path is required: " + tx.Name)
+		}
+		tpl, err := template.New("").Parse(tx.UrlPath)
+		if err != nil {
+			return fmt.Errorf("taxonomies: %v url_path: %w", tx.Name, err)
+		}
+		htpl, err := htemplate.New("").Parse(tx.Title)
+		if err != nil {
+			return fmt.Errorf("taxonomies: %v title: %w", tx.Name, err)
+		}
+		app.tplcahe[tx.Name] = tpl
+		app.htplcahe[tx.Name] = htpl
+	}
+	return nil
+}
+
+// Terms returns terms of the taxonomy of the article.
+func (art *Article) Terms(taxonomy string) []string {
+	return art.terms[taxonomy]
+}
+
+// headerTerms returns comma separated or listed values of the header.
+func (art *Article) headerTerms(name string) []string {
+	terms := []string{}
+	var add func(v interface{})
+	add = func(v interface{}) {
+		switch v := v.(type) {
+		case string:
+			for _, term := range strings.Split(v, ",") {
+				if term = strings.TrimSpace(term); len(term) != 0 {
+					terms = append(terms, term)
+				}
+			}
+		case []interface{}:
+			for _, elem := range v {
+				add(elem)
+			}
+		case nil:
+		default:
+			add(fmt.Sprint(v))
+		}
+	}
+	add(art.headers[name])
+	return terms
+}
+
+// setTerms sets terms of the taxonomies from the article headers.
+func (app *Application) setTerms(art *Article) {
+	art.terms = map[string][]string{}
+	for _, tx := range app.Config.Taxonomies {
+		art.terms[tx.Name] = art.headerTerms(tx.header())
+	}
+}
+
+// buildTermLists renders paginated list pages of each term of a grouping
+// like a taxonomy. name is the url_path name and key is the name of the term
+// in url_path and title data. vu sets the term to the view model.
+func buildTermLists(app *Application, renderer *renderer, lst, name, key string, terms ArticleMap,
+	vu func(vm *viewModel, term string)) error {
+	for term, arts := range terms {
+		term := term
+		if err := buildList(app, renderer, lst, name, arts,
+			func() map[any]any {
+				return H("App", app, key, term)
+			},
+			func(vm *viewModel) {
+				vu(vm, term)
+			}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func buildTaxonomies(app *Application, renderer *renderer) error {
+	for _, tx := range app.Config.Taxonomies {
+		name := tx.Name
+		if err := buildTermLists(app, renderer, tx.template(), name, "Term", app.Taxonomies[name],
+			func(vm *viewModel, term string) {
+				vm.Taxonomy = name
+				vm.Term = term
+			}); err != nil {
+			return err
+		}
+		app.Log("%d %v pages", app.Stats.Get(name), name)
+	}
+	return nil
+}
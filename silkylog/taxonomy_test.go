@@ -0,0 +1,30 @@
+package silkylog
+
+import (
+	"testing"
+)
+
+func TestCompileTaxonomyTemplates(t *testing.T) {
+	app := newTestApplication()
+	app.Config.Taxonomies = []TaxonomyConfig{
+		{Name: "Categories", UrlPath: "categories/{{ .Term }}/index.html", Title: "{{ .Term }}"},
+	}
+	if err := app.compileTemplates(); err != nil {
+		t.Fatal(err)
+	}
+	if path, err := app.Path("Categories", H("Term", "go")); err != nil || path != "categories/go/index.html" {
+		t.Errorf("path: %q, %v", path, err)
+	}
+
+	// taxonomies removed from the config no longer resolve after recompiling
+	app.Config.Taxonomies = nil
+	if err := app.compileTemplates(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := app.Path("Categories", H("Term", "go")); err == nil {
+		t.Error("path of a removed taxonomy resolved")
+	}
+	if _, err := app.Title("Categories", H("Term", "go")); err == nil {
+		t.Error("title of a removed taxonomy resolved")
+	}
+}
@@ -0,0 +1,132 @@
+package silkylog
+
+import (
+	"html"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+var reHTMLTag = regexp.MustCompile(`(?s)<!--.*?-->|<[^>]*>`)
+
+var reSpaces = regexp.MustCompile(`\s+`)
+
+// stripHTML removes tags from the html and returns the plain text.
+func stripHTML(s string) string {
+	s = reHTMLTag.ReplaceAllString(s, " ")
+	s = html.UnescapeString(s)
+	return strings.TrimSpace(reSpaces.ReplaceAllString(s, " "))
+}
+
+// isCJK returns true if the rune is a CJK character. A CJK character is
+// counted as a word because CJK text has no spaces between words.
+func isCJK(r rune) bool {
+	if r < 0x2e80 {
+		// fast path for latin text, CJK blocks start at U+2E80
+		return false
+	}
+	// 'ー' and '々' are not in the Katakana and Han scripts
+	return r == 'ー' || r == '々' ||
+		unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul)
+}
+
+// scanWords calls fn with the byte offset of every word in the plain text
+// until fn returns false. cjk is true if the word is a CJK character.
+func scanWords(s string, fn func(i int, cjk bool) bool) {
+	inWord := false
+	for i, r := range s {
+		cjk := false
+		switch {
+		case unicode.IsSpace(r):
+			inWord = false
+			continue
+		case isCJK(r):
+			inWord = false
+			cjk = true
+		case inWord:
+			continue
+		case unicode.IsPunct(r):
+			// punctuations do not start words
+			continue
+		default:
+			inWord = true
+		}
+		if !fn(i, cjk) {
+			return
+		}
+	}
+}
+
+// truncateWords truncates the plain text to n words and returns true if the
+// text was truncated.
+func truncateWords(s string, n int) (string, bool) {
+	count := 0
+	truncated := -1
+	scanWords(s, func(i int, cjk bool) bool {
+		count++
+		if count > n {
+			truncated = i
+			return false
+		}
+		return true
+	})
+	if truncated < 0 {
+		return s, false
+	}
+	return strings.TrimRightFunc(s[:truncated], unicode.IsSpace), true
+}
+
+// countWords returns the number of non-CJK words and CJK characters in the
+// plain text.
+func countWords(s string) (words, cjk int) {
+	scanWords(s, func(i int, c bool) bool {
+		if c {
+			cjk++
+		} else {
+			words++
+		}
+		return true
+	})
+	return
+}
+
+// tokenize splits the text into lower-cased words. Runs of CJK characters
+// are split into bigrams because CJK text has no spaces between words.
+func tokenize(s string) []string {
+	tokens := []string{}
+	word := []rune{}
+	flush := func() {
+		if len(word) == 0 {
+			return
+		}
+		if isCJK(word[0]) {
+			if len(word) == 1 {
+				tokens = append(tokens, string(word))
+			}
+			for i := 0; i+1 < len(word); i++ {
+				tokens = append(tokens, string(word[i:i+2]))
+			}
+		} else {
+			tokens = append(tokens, strings.ToLower(string(word)))
+		}
+		word = word[:0]
+	}
+	for _, r := range s {
+		switch {
+		case isCJK(r):
+			if len(word) != 0 && !isCJK(word[0]) {
+				flush()
+			}
+			word = append(word, r)
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if len(word) != 0 && isCJK(word[0]) {
+				flush()
+			}
+			word = append(word, r)
+		default:
+			flush()
+		}
+	}
+	flush()
+	return tokens
+}
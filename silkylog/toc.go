@@ -0,0 +1,145 @@
+package silkylog
+
+import (
+	"fmt"
+	"html"
+	"html/template"
+	"regexp"
+	"strings"
+	"unicode"
+
+	lua "github.com/yuin/gopher-lua"
+)
+
+const (
+	defaultTOCMinLevel = 2
+	defaultTOCMaxLevel = 4
+)
+
+// TOCConfig configures tables of contents.
+type TOCConfig struct {
+	MinLevel int
+	MaxLevel int
+}
+
+// TOCItem is a heading in a table of contents.
+type TOCItem struct {
+	Level    int
+	ID       string
+	Text     string
+	Children TOC
+}
+
+// TOC is a heading tree of an article.
+type TOC []*TOCItem
+
+var reHeading = regexp.MustCompile(`(?is)<h([1-6])((?:\s[^>]*)?)>(.*?)</h[1-6]\s*>`)
+
+var reIDAttr = regexp.MustCompile(`(?i)\sid\s*=\s*(?:"([^"]*)"|'([^']*)')`)
+
+// HTML renders the table of contents as nested lists.
+func (toc TOC) HTML() template.HTML {
+	if len(toc) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	toc.writeHTML(&b)
+	return template.HTML(b.String())
+}
+
+func (toc TOC) writeHTML(b *strings.Builder) {
+	b.WriteString("<ul>")
+	for _, item := range toc {
+		fmt.Fprintf(b, `<li><a href="#%v">%v</a>`, html.EscapeString(item.ID), html.EscapeString(item.Text))
+		if len(item.Children) != 0 {
+			item.Children.writeHTML(b)
+		}
+		b.WriteString("</li>")
+	}
+	b.WriteString("</ul>")
+}
+
+// ToLua converts the table of contents into a Lua array of items.
+func (toc TOC) ToLua(L *lua.LState) *lua.LTable {
+	tb := L.NewTable()
+	for _, item := range toc {
+		itb := L.NewTable()
+		itb.RawSetString("level", lua.LNumber(item.Level))
+		itb.RawSetString("id", lua.LString(item.ID))
+		itb.RawSetString("text", lua.LString(item.Text))
+		itb.RawSetString("children", item.Children.ToLua(L))
+		tb.Append(itb)
+	}
+	return tb
+}
+
+func (app *Application) tocLevels() (int, int) {
+	lo, hi := app.Config.TOC.MinLevel, app.Config.TOC.MaxLevel
+	if lo <= 0 {
+		lo = defaultTOCMinLevel
+	}
+	if hi <= 0 {
+		hi = defaultTOCMaxLevel
+	}
+	return lo, hi
+}
+
+// buildTOC extracts headings between the lo and hi level from the html.
+// Headings without an id are given one, so the returned html may differ
+// from the given one.
+func buildTOC(s string, lo, hi int) (string, TOC) {
+	used := map[string]bool{}
+	for _, m := range reIDAttr.FindAllStringSubmatch(s, -1) {
+		used[m[1]+m[2]] = true
+	}
+	toc := TOC{}
+	var stack []*TOCItem
+	s = reHeading.ReplaceAllStringFunc(s, func(h string) string {
+		m := reHeading.FindStringSubmatch(h)
+		level := int(m[1][0] - '0')
+		if level < lo || level > hi {
+			return h
+		}
+		item := &TOCItem{Level: level, Text: stripHTML(m[3])}
+		if id := reIDAttr.FindStringSubmatch(m[2]); id != nil {
+			item.ID = html.UnescapeString(id[1] + id[2])
+		} else {
+			item.ID = headingID(item.Text, used)
+			h = fmt.Sprintf(`<h%v id="%v"%v>%v</h%v>`, m[1], html.EscapeString(item.ID), m[2], m[3], m[1])
+		}
+		for len(stack) != 0 && stack[len(stack)-1].Level >= level {
+			stack = stack[:len(stack)-1]
+		}
+		if len(stack) == 0 {
+			toc = append(toc, item)
+		} else {
+			parent := stack[len(stack)-1]
+			parent.Children = append(parent.Children, item)
+		}
+		stack = append(stack, item)
+		return h
+	})
+	return s, toc
+}
+
+// headingID makes an unique id from the heading text.
+func headingID(text string, used map[string]bool) string {
+	id := strings.Map(func(r rune) rune {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '-' || r == '_':
+			return unicode.ToLower(r)
+		case unicode.IsSpace(r):
+			return '-'
+		}
+		return -1
+	}, text)
+	if len(id) == 0 {
+		id = "heading"
+	}
+	base := id
+	for i := 1; used[id]; i++ {
+		id = fmt.Sprintf("%v-%d", base, i)
+	}
+	used[id] = true
+	return id
+}
@@ -0,0 +1,95 @@
+package silkylog
+
+import (
+	"reflect"
+	"testing"
+)
+
+type tocEntry struct {
+	level int
+	id    string
+	text  string
+	depth int
+}
+
+func flattenTOC(toc TOC, depth int) []tocEntry {
+	entries := []tocEntry{}
+	for _, item := range toc {
+		entries = append(entries, tocEntry{item.Level, item.ID, item.Text, depth})
+		entries = append(entries, flattenTOC(item.Children, depth+1)...)
+	}
+	return entries
+}
+
+func TestBuildTOC(t *testing.T) {
+	cases := []struct {
+		name    string
+		html    string
+		lo, hi  int
+		want    []tocEntry
+		outhtml string
+	}{
+		{
+			name:    "nested",
+			html:    "<h2>Intro</h2><h3>Sub <em>one</em></h3><h2>Next</h2>",
+			lo:      2,
+			hi:      4,
+			want:    []tocEntry{{2, "intro", "Intro", 0}, {3, "sub-one", "Sub one", 1}, {2, "next", "Next", 0}},
+			outhtml: `<h2 id="intro">Intro</h2><h3 id="sub-one">Sub <em>one</em></h3><h2 id="next">Next</h2>`,
+		},
+		{
+			name: "duplicate headings",
+			html: "<h2>Usage</h2><h2>Usage</h2><h2>Usage</h2>",
+			lo:   2,
+			hi:   4,
+			want: []tocEntry{{2, "usage", "Usage", 0}, {2, "usage-1", "Usage", 0}, {2, "usage-2", "Usage", 0}},
+		},
+		{
+			name: "existing ids are kept and avoided",
+			html: `<p id="faq"></p><h2 id='setup'>Set up</h2><h2>FAQ</h2>`,
+			lo:   2,
+			hi:   4,
+			want: []tocEntry{{2, "setup", "Set up", 0}, {2, "faq-1", "FAQ", 0}},
+		},
+		{
+			name:    "levels out of range",
+			html:    "<h1>Title</h1><h2>Body</h2><h5>Deep</h5>",
+			lo:      2,
+			hi:      4,
+			want:    []tocEntry{{2, "body", "Body", 0}},
+			outhtml: `<h1>Title</h1><h2 id="body">Body</h2><h5>Deep</h5>`,
+		},
+		{
+			name: "deeper heading first",
+			html: "<h3>Deep</h3><h2>Top</h2><h4>Deeper</h4>",
+			lo:   2,
+			hi:   4,
+			want: []tocEntry{{3, "deep", "Deep", 0}, {2, "top", "Top", 0}, {4, "deeper", "Deeper", 1}},
+		},
+		{
+			name: "symbols only",
+			html: "<h2>!!!</h2><h2>日本語 見出し</h2>",
+			lo:   2,
+			hi:   4,
+			want: []tocEntry{{2, "heading", "!!!", 0}, {2, "日本語-見出し", "日本語 見出し", 0}},
+		},
+		{
+			name: "no headings",
+			html: "<p>text</p>",
+			lo:   2,
+			hi:   4,
+			want: []tocEntry{},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			out, toc := buildTOC(c.html, c.lo, c.hi)
+			if got := flattenTOC(toc, 0); !reflect.DeepEqual(got, c.want) {
+				t.Errorf("toc: got %v, want %v", got, c.want)
+			}
+			if len(c.outhtml) != 0 && out != c.outhtml {
+				t.Errorf("html: got %q, want %q", out, c.outhtml)
+			}
+		})
+	}
+}
@@ -1,4 +1,4 @@
-package main
+package silkylog
 
 import (
 	"archive/zip"
@@ -65,6 +65,8 @@ func makeSlug(s string) string {
 	return strings.ToLower(s)
 }
 
+// H returns a map of the key and value pairs. Templates use it to pass
+// named arguments like (H "Tag" $tag "Page" 0).
 func H(args ...interface{}) map[interface{}]interface{} {
 	ret := make(map[interface{}]interface{})
 	for i := 0; i < len(args); i += 2 {
@@ -107,6 +109,11 @@ func isFile(path string) bool { return pathFilePath(path) == ftFile }
 
 func pathExists(path string) bool { return pathFilePath(path) != ftNotExists }
 
+func isSubPath(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
 func ensureDirExists(path string) error {
 	dir := filepath.Dir(path)
 	if !pathExists(dir) {
@@ -258,29 +265,28 @@ func execHtemplate(t *htemplate.Template, any interface{}) (string, error) {
 	return b.String(), nil
 }
 
-func exitApplication(msg string, code int) {
-	out := os.Stdout
-	if code != 0 {
-		out = os.Stderr
+type multiError []error
+
+func (errs multiError) Error() string {
+	msgs := make([]string, 0, len(errs))
+	for _, err := range errs {
+		msgs = append(msgs, err.Error())
 	}
-	fmt.Fprint(out, msg)
-	fmt.Fprint(out, "\n")
-	os.Exit(code)
+	return strings.Join(msgs, "\n")
 }
 
 func goToLua(L *lua.LState, v interface{}) lua.LValue {
-	var art article
-	at := reflect.TypeOf(art)
+	at := reflect.TypeOf((*Article)(nil)).Elem()
 	rv := reflect.ValueOf(v)
 	kind := rv.Kind()
 	switch {
 	case kind == 0:
 		return lua.LNil
-	case reflect.Int >= kind && kind <= reflect.Int64:
+	case kind >= reflect.Int && kind <= reflect.Int64:
 		return lua.LNumber(rv.Int())
-	case reflect.Uint >= kind && kind <= reflect.Uintptr:
+	case kind >= reflect.Uint && kind <= reflect.Uintptr:
 		return lua.LNumber(rv.Uint())
-	case reflect.Float32 >= kind && kind <= reflect.Float64:
+	case kind >= reflect.Float32 && kind <= reflect.Float64:
 		return lua.LNumber(rv.Float())
 	case kind == reflect.String:
 		return lua.LString(rv.String())
@@ -289,8 +295,10 @@ func goToLua(L *lua.LState, v interface{}) lua.LValue {
 			return lua.LTrue
 		}
 		return lua.LFalse
+	case rv.Type() == reflect.TypeOf(time.Time{}):
+		return timeToLuaTable(L, v.(time.Time))
 	case kind == reflect.Ptr && rv.Elem().Type() == at:
-		return rv.Interface().(*article).ToLua(L)
+		return rv.Interface().(*Article).ToLua(L)
 	case kind == reflect.Slice:
 		tb := L.NewTable()
 		for i := 0; i < rv.Len(); i++ {
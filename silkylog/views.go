@@ -1,4 +1,4 @@
-package main
+package silkylog
 
 import (
 	"bytes"
@@ -23,11 +23,15 @@ type renderer struct {
 
 type viewModel struct {
 	L         *lua.LState
-	App       *application
+	App       *Application
 	PageTitle string
-	Article   *article
-	Articles  []*article
+	Article   *Article
+	Articles  []*Article
 	Tag       string
+	Taxonomy  string
+	Term      string
+	Series    *Series
+	Author    *Author
 	Year      int
 	Month     int
 	Start     int
@@ -39,9 +43,10 @@ type viewModel struct {
 	IsLast    bool
 	PathData  interface{}
 	ListName  string
+	Feed      *FeedChannel
 }
 
-func newViewModel(app *application, title string, art *article) *viewModel {
+func newViewModel(app *Application, title string, art *Article) *viewModel {
 	return &viewModel{
 		App:       app,
 		PageTitle: title,
@@ -49,21 +54,23 @@ func newViewModel(app *application, title string, art *article) *viewModel {
 	}
 }
 
-func (vm *viewModel) SetPage(page, step int, arts []*article, pd interface{}, ln string) bool {
-	vm.Start = intMin((page-1)*step, len(arts)-1)
+func (vm *viewModel) SetPage(page, step int, arts []*Article, pd interface{}, ln string) bool {
+	// an empty list still renders its first page, e.g. the index of a site
+	// that only has scheduled articles
+	vm.Start = intMax(intMin((page-1)*step, len(arts)-1), 0)
 	vm.End = intMin(vm.Start+step, len(arts))
 	vm.Page = page
 	vm.Articles = arts[vm.Start:vm.End]
 	vm.IsLast = vm.End == len(arts)
 	vm.IsFirst = page == 1
-	vm.LastPage = int(math.Ceil(float64(len(arts)) / float64(step)))
+	vm.LastPage = intMax(int(math.Ceil(float64(len(arts))/float64(step))), 1)
 	vm.Step = step
 	vm.PathData = pd
 	vm.ListName = ln
 	return vm.IsLast
 }
 
-func (vm *viewModel) Lua(name string, args ...interface{}) template.HTML {
+func (vm *viewModel) Lua(name string, args ...interface{}) (template.HTML, error) {
 	L := vm.L
 	fn := L.Get(lua.GlobalsIndex)
 	for _, name := range strings.Split(name, ".") {
@@ -78,10 +85,12 @@ func (vm *viewModel) Lua(name string, args ...interface{}) template.HTML {
 			}
 			L.Push(lv)
 		}
-		L.Call(len(args), 1)
-		return template.HTML(luaPop(L).String())
+		if err := L.PCall(len(args), 1, nil); err != nil {
+			return "", err
+		}
+		return template.HTML(luaPop(L).String()), nil
 	}
-	return template.HTML(fn.String())
+	return template.HTML(fn.String()), nil
 }
 
 func (vm *viewModel) LValue(v interface{}) lua.LValue {
@@ -125,7 +134,17 @@ var funcMap = template.FuncMap{
 		j = intMax(intMin(j, len(s)), 0)
 		return s[i:j]
 	},
-	"H": H,
+	"H":   H,
+	"toc": func(toc TOC) template.HTML { return toc.HTML() },
+}
+
+// themePage returns the page template name if the theme has it, or the
+// fallback. Themes written before a page type was added keep working.
+func (app *Application) themePage(name, fallback string) string {
+	if isFile(filepath.Join(app.Config.ThemeDir, app.Config.Theme, "pages", name+".html")) {
+		return name
+	}
+	return fallback
 }
 
 func (rd *renderer) loadTemplate(path string) error {
@@ -144,9 +163,12 @@ func (rd *renderer) loadTemplate(path string) error {
 	return nil
 }
 
-func (rd *renderer) Render(_ *application, path string, data *viewModel) (string, error) {
-	L := luaPool.Get()
-	defer luaPool.Put(L)
+func (rd *renderer) Render(app *Application, path string, data *viewModel) (string, error) {
+	L, err := app.luaPool.Get()
+	if err != nil {
+		return "", err
+	}
+	defer app.luaPool.Put(L)
 	rd.m.Lock()
 	defer rd.m.Unlock()
 	data.L = L
@@ -165,18 +187,24 @@ func (rd *renderer) Render(_ *application, path string, data *viewModel) (string
 	return buf.String(), nil
 }
 
-func (rd *renderer) RenderType(app *application, name string, typ string, data *viewModel) (string, error) {
-	L := luaPool.Get()
-	defer luaPool.Put(L)
+func (rd *renderer) RenderType(app *Application, name string, typ string, data *viewModel) (string, error) {
+	L, err := app.luaPool.Get()
+	if err != nil {
+		return "", err
+	}
+	defer app.luaPool.Put(L)
 	data.L = L
 	themebase := filepath.Join(app.Config.ThemeDir, app.Config.Theme)
 	path := filepath.Join(themebase, typ, name)
 	return rd.Render(app, path, data)
 }
 
-func (rd *renderer) RenderPage(app *application, name string, data *viewModel) (string, error) {
-	L := luaPool.Get()
-	defer luaPool.Put(L)
+func (rd *renderer) RenderPage(app *Application, name string, data *viewModel) (string, error) {
+	L, err := app.luaPool.Get()
+	if err != nil {
+		return "", err
+	}
+	defer app.luaPool.Put(L)
 	rd.m.Lock()
 	defer rd.m.Unlock()
 	data.L = L
@@ -231,11 +259,16 @@ func (rd *renderer) RenderPage(app *application, name string, data *viewModel) (
 	return buf.String(), nil
 }
 
-func defaultPagenator(vm *viewModel, anchor string) template.HTML {
+func defaultPagenator(vm *viewModel, anchor string) (template.HTML, error) {
 	pd := vm.PathData.(map[interface{}]interface{})
+	var err error
 	pagelink := func(page int) string {
 		pd["Page"] = page
-		return vm.App.Url(vm.ListName, pd)
+		url, uerr := vm.App.Url(vm.ListName, pd)
+		if uerr != nil {
+			err = uerr
+		}
+		return url
 	}
 	page := vm.Page
 	maxpage := vm.LastPage
@@ -277,5 +310,8 @@ func defaultPagenator(vm *viewModel, anchor string) template.HTML {
 			"rel=\"next\" class=\"%s\">Next&raquo;</a></li>", pagelink(page+1), anchor))
 	}
 	tpl = append(tpl, "</ul></nav>")
-	return template.HTML(strings.Join(tpl, ""))
+	if err != nil {
+		return "", err
+	}
+	return template.HTML(strings.Join(tpl, "")), nil
 }
@@ -0,0 +1,40 @@
+package silkylog
+
+import (
+	"testing"
+)
+
+func TestViewModelSetPage(t *testing.T) {
+	arts := make([]*Article, 5)
+	for i := range arts {
+		arts[i] = &Article{Slug: string(rune('a' + i))}
+	}
+	cases := []struct {
+		name       string
+		page, step int
+		arts       []*Article
+		start, end int
+		last       bool
+		lastPage   int
+	}{
+		{name: "first page", page: 1, step: 2, arts: arts, start: 0, end: 2, last: false, lastPage: 3},
+		{name: "last page", page: 3, step: 2, arts: arts, start: 4, end: 5, last: true, lastPage: 3},
+		{name: "exact pages", page: 1, step: 5, arts: arts, start: 0, end: 5, last: true, lastPage: 1},
+		{name: "empty", page: 1, step: 2, arts: []*Article{}, start: 0, end: 0, last: true, lastPage: 1},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			vm := newViewModel(nil, "", nil)
+			last := vm.SetPage(c.page, c.step, c.arts, nil, "Index")
+			if vm.Start != c.start || vm.End != c.end || len(vm.Articles) != c.end-c.start {
+				t.Errorf("range: got %d:%d (%d articles), want %d:%d", vm.Start, vm.End, len(vm.Articles), c.start, c.end)
+			}
+			if last != c.last || vm.IsLast != c.last {
+				t.Errorf("last: got %v, want %v", last, c.last)
+			}
+			if vm.LastPage != c.lastPage {
+				t.Errorf("last page: got %d, want %d", vm.LastPage, c.lastPage)
+			}
+		})
+	}
+}